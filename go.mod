@@ -4,7 +4,11 @@ go 1.22.7
 
 toolchain go1.23.4
 
-require github.com/grafana/grafana-plugin-sdk-go v0.263.0
+require (
+	github.com/Azure/go-ntlmssp v0.0.0-20221128193559-754e69321358
+	github.com/grafana/grafana-plugin-sdk-go v0.263.0
+	golang.org/x/sync v0.10.0
+)
 
 require (
 	github.com/BurntSushi/toml v1.4.0 // indirect
@@ -78,10 +82,10 @@ require (
 	go.opentelemetry.io/otel/sdk v1.33.0 // indirect
 	go.opentelemetry.io/otel/trace v1.33.0 // indirect
 	go.opentelemetry.io/proto/otlp v1.4.0 // indirect
+	golang.org/x/crypto v0.32.0 // indirect
 	golang.org/x/exp v0.0.0-20240909161429-701f63a606c0 // indirect
 	golang.org/x/mod v0.22.0 // indirect
 	golang.org/x/net v0.34.0 // indirect
-	golang.org/x/sync v0.10.0 // indirect
 	golang.org/x/sys v0.29.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 	golang.org/x/tools v0.28.0 // indirect
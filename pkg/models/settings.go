@@ -7,12 +7,33 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
+// Authentication modes supported against the SCOM web console.
+const (
+	// AuthModeBasic posts the user name and password to /OperationsManager/authenticate.
+	AuthModeBasic = "basic"
+	// AuthModeWindows performs an NTLM/Negotiate handshake with the SCOM web console.
+	AuthModeWindows = "windows"
+)
+
+const (
+	// DefaultSessionTimeout is the SCOM web console session lifetime in minutes, used when none is configured.
+	DefaultSessionTimeout = 20
+	// DefaultMaxConcurrency is the number of parallel SCOM requests per datasource, used when none is configured.
+	DefaultMaxConcurrency = 10
+)
+
 type PluginSettings struct {
 	Path                 string                `json:"path"`
 	Secrets              *SecretPluginSettings `json:"-"`
 	Url                  string                `json:"url"`
 	UserName             string                `json:"userName"`
 	IsSkipTlsVerifyCheck bool                  `json:"isSkipTlsVerifyCheck"`
+	AuthMode             string                `json:"authMode"`
+	SessionTimeout       int                   `json:"sessionTimeout"`
+	MaxConcurrency       int                   `json:"maxConcurrency"`
+	// ServerExtension enables the features that need endpoints outside the SCOM REST API, served by
+	// an extension installed on the web console server.
+	ServerExtension bool `json:"serverExtension"`
 }
 
 type SecretPluginSettings struct {
@@ -30,6 +51,22 @@ func LoadPluginSettings(source backend.DataSourceInstanceSettings) (*PluginSetti
 	// TODO
 	//log.Println("********************* TLS VALUE !! > ", settings.IsSkipTlsVerifyCheck)
 
+	switch settings.AuthMode {
+	case "":
+		settings.AuthMode = AuthModeBasic
+	case AuthModeBasic, AuthModeWindows:
+	default:
+		return nil, fmt.Errorf("unknown authentication mode: %s", settings.AuthMode)
+	}
+
+	if settings.SessionTimeout <= 0 {
+		settings.SessionTimeout = DefaultSessionTimeout
+	}
+
+	if settings.MaxConcurrency <= 0 {
+		settings.MaxConcurrency = DefaultMaxConcurrency
+	}
+
 	settings.Secrets = loadSecretPluginSettings(source.DecryptedSecureJSONData)
 
 	return &settings, nil
@@ -1,33 +1,205 @@
 package models
 
+import (
+	"encoding/json"
+	"strings"
+)
+
 // Base struct for all queries
 type ScomQuery struct {
 	Type string `json:"type"`
 }
 
-// StateQuery struct
-type StateQuery struct {
-	ScomQuery
+// ObjectSelection selects the monitoring objects of a query by class, group and instance.
+type ObjectSelection struct {
 	Classes   []MonitoringClass  `json:"classes"`
 	Groups    []ScomGroup        `json:"groups"`
 	Instances []MonitoringObject `json:"instances"`
 }
 
+// StateQuery struct
+type StateQuery struct {
+	ScomQuery
+	ObjectSelection
+}
+
+// HealthExplorerQuery returns the monitor rollup tree of the selected objects.
+type HealthExplorerQuery struct {
+	ScomQuery
+	ObjectSelection
+}
+
+// StateHistoryQuery returns the health state transitions of the selected objects over the query
+// time range.
+type StateHistoryQuery struct {
+	ScomQuery
+	ObjectSelection
+	// Level is one of the StateHistoryLevel constants. Empty means object.
+	Level string `json:"level"`
+}
+
+// Levels of state history queries.
+const (
+	// StateHistoryLevelObject returns a series per object.
+	StateHistoryLevelObject = "object"
+	// StateHistoryLevelMonitor returns a series per monitor of every object.
+	StateHistoryLevelMonitor = "monitor"
+)
+
+// AvailabilityQuery returns the share of the query time range the selected objects, and the
+// groups they were selected through, spent in every health state.
+type AvailabilityQuery struct {
+	ScomQuery
+	ObjectSelection
+	// ExcludeMaintenance leaves maintenance windows out of the time availability is computed over,
+	// instead of counting them as downtime.
+	ExcludeMaintenance bool `json:"excludeMaintenance"`
+}
+
 // AlertQuery struct
 type AlertQuery struct {
 	ScomQuery
 	Criteria string `json:"criteria"`
+	// TimeFilter restricts alerts to the query time range, one of the AlertTimeFilter constants.
+	// Empty returns alerts regardless of time.
+	TimeFilter string `json:"timeFilter"`
+	// Columns are the SCOM alert properties returned, in order. Empty returns the default columns.
+	Columns []string `json:"columns"`
+	// Filter is compiled into criteria when no raw Criteria is given.
+	Filter *AlertFilter `json:"filter"`
+}
+
+// AlertAggregationQuery counts the alerts matched by the embedded alert query per group.
+type AlertAggregationQuery struct {
+	AlertQuery
+	// GroupBy lists the AlertGroupBy properties alerts are grouped by, in order.
+	GroupBy []string `json:"groupBy"`
+}
+
+// AlertAnnotationQuery returns the alerts matched by the embedded alert query as annotations.
+// Without a time filter, alerts active at any point of the query time range are returned.
+type AlertAnnotationQuery struct {
+	AlertQuery
+}
+
+// Alert properties alert counts can be grouped by.
+const (
+	AlertGroupBySeverity        = "severity"
+	AlertGroupByResolutionState = "resolutionstate"
+	AlertGroupByObject          = "object"
+	AlertGroupByClass           = "class"
+	AlertGroupByName            = "name"
+)
+
+// AlertFilter is a structured alert criteria. Conditions are combined with AND, values within a
+// condition with OR. Empty conditions are ignored.
+type AlertFilter struct {
+	// Severities by name (Information, Warning, Error/Critical) or number (0-2).
+	Severities []string `json:"severities"`
+	// Priorities by name (Low, Normal, High) or number (0-2).
+	Priorities []string `json:"priorities"`
+	// ResolutionStates by number, e.g. 0 for New and 255 for Closed.
+	ResolutionStates []int `json:"resolutionStates"`
+	// ObjectIds of the monitoring objects that raised the alerts.
+	ObjectIds []string `json:"objectIds"`
+	// ClassIds of the monitoring classes of the objects that raised the alerts.
+	ClassIds []string `json:"classIds"`
+	// NameContains matches alerts whose name contains the text.
+	NameContains string `json:"nameContains"`
+	// Owner matches the alert owner exactly.
+	Owner string `json:"owner"`
+	// MaxAgeMinutes matches alerts raised within the last number of minutes.
+	MaxAgeMinutes int `json:"maxAgeMinutes"`
+}
+
+// Alert time range filters.
+const (
+	// AlertTimeFilterRaised keeps alerts raised within the time range.
+	AlertTimeFilterRaised = "raised"
+	// AlertTimeFilterModified keeps alerts last modified within the time range.
+	AlertTimeFilterModified = "modified"
+)
+
+// MaintenanceQuery returns the maintenance mode windows of the selected objects as annotation regions.
+type MaintenanceQuery struct {
+	ScomQuery
+	ObjectSelection
 }
 
 // PerformanceQuery struct
 type PerformanceQuery struct {
 	ScomQuery
-	Classes   []MonitoringClass    `json:"classes"`
-	Counters  []PerformanceCounter `json:"counters"`
-	Groups    []ScomGroup          `json:"groups"`
-	Instances []MonitoringObject   `json:"instances"`
+	ObjectSelection
+	Counters []PerformanceCounter `json:"counters"`
+	// Aggregation applied per interval bucket when resampling series. Empty averages buckets
+	// only when a series has more samples than the panel can display.
+	Aggregation string `json:"aggregation"`
+	// Format of the returned frames, one of the PerformanceFormat constants. Empty means table.
+	Format string `json:"format"`
+	// Mode selects between full series and per-series statistics, one of the PerformanceMode constants.
+	Mode string `json:"mode"`
+	// Ranking keeps only the highest or lowest ranked series. Nil returns all series.
+	Ranking *PerformanceRanking `json:"ranking"`
+	// Rollup aggregates the members of every selected group into a single series per counter.
+	// Nil returns a series per member.
+	Rollup *PerformanceRollup `json:"rollup"`
+}
+
+// PerformanceRollup aggregates group members per timestamp.
+type PerformanceRollup struct {
+	// Aggregation is AggregationAvg, AggregationSum, AggregationMin, AggregationMax or AggregationPercentile.
+	Aggregation string `json:"aggregation"`
+	// Percentile between 0 and 100, used with AggregationPercentile.
+	Percentile float64 `json:"percentile"`
+}
+
+// PerformanceRanking selects the top or bottom series by a statistic over the query range.
+type PerformanceRanking struct {
+	// Order is RankingTop or RankingBottom.
+	Order string `json:"order"`
+	// Limit is the number of series to keep.
+	Limit int `json:"limit"`
+	// By is the statistic series are ranked by: AggregationAvg, AggregationMax or AggregationLast.
+	By string `json:"by"`
 }
 
+// Ranking orders.
+const (
+	RankingTop    = "top"
+	RankingBottom = "bottom"
+)
+
+// Modes of performance queries.
+const (
+	// PerformanceModeSeries returns the samples of every series.
+	PerformanceModeSeries = "series"
+	// PerformanceModeSummary returns one row of average, minimum, maximum and last value per series.
+	PerformanceModeSummary = "summary"
+)
+
+// Output formats of performance queries.
+const (
+	// PerformanceFormatTable returns a frame per series with object and counter columns on every row.
+	PerformanceFormatTable = "table"
+	// PerformanceFormatTimeSeries returns a labelled numeric frame per series (multi-frame long).
+	PerformanceFormatTimeSeries = "timeseries"
+	// PerformanceFormatWide returns a single frame with a shared time field and a labelled field per series.
+	PerformanceFormatWide = "wide"
+)
+
+// Aggregations available for resampling performance series.
+const (
+	AggregationNone  = "none"
+	AggregationAvg   = "avg"
+	AggregationMin   = "min"
+	AggregationMax   = "max"
+	AggregationLast  = "last"
+	AggregationSum   = "sum"
+	AggregationCount = "count"
+	// AggregationPercentile is only available for group rollups.
+	AggregationPercentile = "percentile"
+)
+
 //Query between frontend and backend
 // type QueryModel struct {
 // 	//Type of data queries. (alerts, performance, state)
@@ -46,24 +218,99 @@ type PerformanceCounter struct {
 }
 
 type ScomAlert struct {
-	TableColumns []struct {
-		Field  string `json:"field"`
-		Header string `json:"header"`
-		Type   string `json:"type"`
-		Hidden bool   `json:"hidden"`
-	} `json:"tableColumns"`
-	Rows []struct {
-		ID                 string  `json:"id"`
-		Severity           string  `json:"severity"`
-		MonitoringObject   string  `json:"monitoringobjectdisplayname"`
-		Name               string  `json:"name"`
-		Age                string  `json:"age"`
-		AgeInMilliseconds  float64 `json:"ageinmilliseconds"`
-		RepeatCount        int64   `json:"repeatcount"`
-		Description        string  `json:"description"`
-		MonitoringObjectId string  `json:"monitoringobjectid"`
-		MonitoringClassId  string  `json:"monitoringclassid"`
-	} `json:"rows"`
+	TableColumns []TableColumn  `json:"tableColumns"`
+	Rows         []ScomAlertRow `json:"rows"`
+}
+
+type TableColumn struct {
+	Field  string `json:"field"`
+	Header string `json:"header"`
+	Type   string `json:"type"`
+	Hidden bool   `json:"hidden"`
+}
+
+// DefaultAlertColumns are the alert properties requested for every alert query. Additional
+// properties selected in a query are requested on top of them.
+var DefaultAlertColumns = []string{"severity", "monitoringobjectdisplayname", "name", "age", "repeatcount", "description", "monitoringobjectid", "monitoringclassid", "timeraised", "lastmodified", "timeresolved", "resolutionstate"}
+
+type ScomAlertRow struct {
+	ID                 string  `json:"id"`
+	Severity           string  `json:"severity"`
+	MonitoringObject   string  `json:"monitoringobjectdisplayname"`
+	Name               string  `json:"name"`
+	Age                string  `json:"age"`
+	AgeInMilliseconds  float64 `json:"ageinmilliseconds"`
+	RepeatCount        int64   `json:"repeatcount"`
+	Description        string  `json:"description"`
+	MonitoringObjectId string  `json:"monitoringobjectid"`
+	MonitoringClassId  string  `json:"monitoringclassid"`
+	TimeRaised         string  `json:"timeraised"`
+	LastModified       string  `json:"lastmodified"`
+	TimeResolved       string  `json:"timeresolved"`
+
+	MonitoringClassName ScomString `json:"monitoringclassname"`
+	ResolutionState     ScomString `json:"resolutionstate"`
+
+	// Fields holds every property of the row as returned by SCOM, keyed by lower case field name.
+	Fields map[string]interface{} `json:"-"`
+}
+
+// UnmarshalJSON decodes the known alert properties and keeps all returned properties in Fields.
+func (r *ScomAlertRow) UnmarshalJSON(b []byte) error {
+	type alias ScomAlertRow
+	var typed alias
+	if err := json.Unmarshal(b, &typed); err != nil {
+		return err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	*r = ScomAlertRow(typed)
+	r.Fields = make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		r.Fields[strings.ToLower(key)] = value
+	}
+
+	return nil
+}
+
+// ResolutionState is an alert resolution state defined in the management group.
+type ResolutionState struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	ResolutionState int    `json:"resolutionState"`
+}
+
+// AlertUpdate changes the resolution state, owner or ticket id of alerts. Nil properties are left
+// unchanged.
+type AlertUpdate struct {
+	AlertIds        []string `json:"alertIds"`
+	ResolutionState *int     `json:"resolutionState,omitempty"`
+	Owner           *string  `json:"owner,omitempty"`
+	TicketID        *string  `json:"ticketId,omitempty"`
+	Comment         string   `json:"comment,omitempty"`
+}
+
+// ScomString decodes a SCOM property that may be returned as a string, number or boolean.
+type ScomString string
+
+func (s *ScomString) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*s = ""
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(b, &value); err == nil {
+		*s = ScomString(value)
+		return nil
+	}
+
+	*s = ScomString(b)
+	return nil
 }
 
 type StateDataRequestBody struct {
@@ -87,6 +334,52 @@ type StateDataResponse struct {
 	Rows         []MonitoringObject `json:"rows"`
 }
 
+// StateChangeEvent is a change of the health state of a monitor of an object.
+type StateChangeEvent struct {
+	MonitorID          string `json:"monitorid"`
+	MonitorName        string `json:"monitorname"`
+	MonitorDisplayName string `json:"monitordisplayname"`
+	OldHealthState     string `json:"oldhealthstate"`
+	NewHealthState     string `json:"newhealthstate"`
+	TimeGenerated      string `json:"timegenerated"`
+	// Object the monitor belongs to.
+	Object MonitoringObject `json:"-"`
+}
+
+type StateChangeResponse struct {
+	TableColumns []TableColumn      `json:"tableColumns"`
+	Rows         []StateChangeEvent `json:"rows"`
+}
+
+// MaintenanceWindow is a period an object spent in maintenance mode.
+type MaintenanceWindow struct {
+	StartTime        string `json:"starttime"`
+	ScheduledEndTime string `json:"scheduledendtime"`
+	// EndTime is empty while the object is still in maintenance mode.
+	EndTime  string `json:"endtime"`
+	Reason   string `json:"reason"`
+	Comments string `json:"comments"`
+	User     string `json:"user"`
+	// Object the window belongs to.
+	Object MonitoringObject `json:"-"`
+}
+
+// MaintenanceMode starts, updates or stops maintenance mode for objects.
+type MaintenanceMode struct {
+	ObjectIds []string `json:"objectIds"`
+	// Recursive includes the objects contained by the objects, such as group members.
+	Recursive bool `json:"recursive"`
+	// EndTime in RFC 3339, empty when stopping.
+	EndTime  string `json:"endTime,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	Comments string `json:"comments"`
+}
+
+type MaintenanceHistoryResponse struct {
+	TableColumns []TableColumn       `json:"tableColumns"`
+	Rows         []MaintenanceWindow `json:"rows"`
+}
+
 type ChildNodeData struct {
 	HealthState        string `json:"healthState"`
 	ID                 string `json:"id"`
@@ -94,6 +387,8 @@ type ChildNodeData struct {
 	MonitorDisplayName string `json:"monitorDisplayName,omitempty"`
 	MonitorName        string `json:"monitorName"`
 	LastTimeModified   string `json:"lastTimeModified"`
+	// ChildNodeDatas are the monitors rolling up into this one.
+	ChildNodeDatas []ChildNodeData `json:"childNodeDatas"`
 }
 
 type MonitoringDataResponse struct {
@@ -110,18 +405,21 @@ type Legend struct {
 		Type   interface{} `json:"type"`
 		Hidden bool        `json:"hidden"`
 	} `json:"tableColumns"`
-	Rows []struct {
-		PerformanceObject   string  `json:"performanceobject"`
-		PerformanceCounter  string  `json:"performancecounter"`
-		PerformanceInstance string  `json:"performanceinstance"`
-		AverageValue        float64 `json:"averagevalue"`
-		MaximumValue        float64 `json:"maximumvalue"`
-		MinimumValue        float64 `json:"minimumvalue"`
-		LastValue           float64 `json:"lastvalue"`
-		Path                string  `json:"path"`
-		Target              string  `json:"target"`
-		ID                  string  `json:"id"`
-	} `json:"rows"`
+	Rows []LegendRow `json:"rows"`
+}
+
+// LegendRow describes a performance dataset and its statistics over the requested duration.
+type LegendRow struct {
+	PerformanceObject   string  `json:"performanceobject"`
+	PerformanceCounter  string  `json:"performancecounter"`
+	PerformanceInstance string  `json:"performanceinstance"`
+	AverageValue        float64 `json:"averagevalue"`
+	MaximumValue        float64 `json:"maximumvalue"`
+	MinimumValue        float64 `json:"minimumvalue"`
+	LastValue           float64 `json:"lastvalue"`
+	Path                string  `json:"path"`
+	Target              string  `json:"target"`
+	ID                  string  `json:"id"`
 }
 
 type Dataset struct {
@@ -130,12 +428,13 @@ type Dataset struct {
 }
 
 type PerformanceResponse struct {
-	Datasets          []Dataset `json:"datasets"`
-	Legends           Legend    `json:"legends"`
-	ObjectId          string    `json:"objectId"`
-	ObjectDisplayName string    `json:"objectDisplayName"`
-	ObjectPath        string    `json:"objectPath"`
-	ObjectFullName    string    `json:"objectFullName"`
+	Datasets          []Dataset   `json:"datasets"`
+	Legends           Legend      `json:"legends"`
+	ObjectId          string      `json:"objectId"`
+	ObjectDisplayName string      `json:"objectDisplayName"`
+	ObjectPath        string      `json:"objectPath"`
+	ObjectFullName    string      `json:"objectFullName"`
+	ObjectScope       ObjectScope `json:"-"`
 }
 
 type ScomPerformanceRequest struct {
@@ -206,6 +505,15 @@ type MonitoringObject struct {
 	FullName        string `json:"fullName"`
 	MaintenanceMode string `json:"maintenancemode"`
 	HealthState     string `json:"healthstate"`
+	// IsAvailable is false for objects whose agent or management server stopped reporting.
+	IsAvailable ScomString  `json:"isavailable"`
+	Scope       ObjectScope `json:"-"`
+}
+
+// ObjectScope records the groups and classes of a query an object was selected through.
+type ObjectScope struct {
+	Groups  []string
+	Classes []string
 }
 
 type ScomObjectResponse struct {
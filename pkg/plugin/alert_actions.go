@@ -0,0 +1,161 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+// Resolution state SCOM closes alerts with.
+const resolutionStateClosed = 255
+
+// Grafana organisation roles allowed to change alerts and maintenance mode.
+var actionRoles = map[string]bool{
+	"Editor": true,
+	"Admin":  true,
+}
+
+// alertActionRequest is the body posted to the alert action routes.
+type alertActionRequest struct {
+	AlertIds        []string `json:"alertIds"`
+	ResolutionState *int     `json:"resolutionState"`
+	Owner           *string  `json:"owner"`
+	TicketID        *string  `json:"ticketId"`
+	Comment         string   `json:"comment"`
+}
+
+// alertActions turn the request of an alert action route into the update sent to SCOM.
+var alertActions = map[string]func(r alertActionRequest) (models.AlertUpdate, error){
+	"closeAlerts": func(r alertActionRequest) (models.AlertUpdate, error) {
+		state := resolutionStateClosed
+		return models.AlertUpdate{ResolutionState: &state, Comment: r.Comment}, nil
+	},
+	"setAlertResolutionState": func(r alertActionRequest) (models.AlertUpdate, error) {
+		if r.ResolutionState == nil {
+			return models.AlertUpdate{}, fmt.Errorf("required property 'resolutionState' is missing")
+		}
+		return models.AlertUpdate{ResolutionState: r.ResolutionState, Comment: r.Comment}, nil
+	},
+	"setAlertOwner": func(r alertActionRequest) (models.AlertUpdate, error) {
+		if r.Owner == nil {
+			return models.AlertUpdate{}, fmt.Errorf("required property 'owner' is missing")
+		}
+		return models.AlertUpdate{Owner: r.Owner, Comment: r.Comment}, nil
+	},
+	"setAlertTicketId": func(r alertActionRequest) (models.AlertUpdate, error) {
+		if r.TicketID == nil {
+			return models.AlertUpdate{}, fmt.Errorf("required property 'ticketId' is missing")
+		}
+		return models.AlertUpdate{TicketID: r.TicketID, Comment: r.Comment}, nil
+	},
+}
+
+// handleAlertAction validates the request of an alert action route, applies the update to the
+// alerts and logs the Grafana user that performed it.
+func (d *ScomDatasource) handleAlertAction(ctx context.Context, req *backend.CallResourceRequest) (interface{}, error) {
+	if err := authorizeAction(req, "update alerts"); err != nil {
+		return nil, err
+	}
+
+	var r alertActionRequest
+	if err := json.Unmarshal(req.Body, &r); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	ids, err := actionIds(r.AlertIds, "alertIds", "alert id")
+	if err != nil {
+		return nil, err
+	}
+
+	update, err := alertActions[req.Path](r)
+	if err != nil {
+		return nil, err
+	}
+	update.AlertIds = ids
+
+	// Custom resolution states are unknown while only the defaults are available, SCOM validates
+	// the state then.
+	if update.ResolutionState != nil {
+		_, ok := d.resolutionStateNames(ctx)[*update.ResolutionState]
+		if !ok && d.resolutionStatesLoaded() {
+			return nil, fmt.Errorf("unknown resolution state: %d", *update.ResolutionState)
+		}
+	}
+
+	user := grafanaUser(req.PluginContext)
+	backend.Logger.Info("Updating alerts", "action", req.Path, "user", user, "alertIds", ids)
+
+	if err := d.client.UpdateAlerts(ctx, update); err != nil {
+		backend.Logger.Warn("Failed to update alerts", "action", req.Path, "user", user, "alertIds", ids, "error", err)
+		return nil, err
+	}
+
+	return update, nil
+}
+
+// statusError is an error a resource route responds to with a status other than bad request.
+type statusError struct {
+	status  int
+	headers map[string][]string
+	err     error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// authorizeAction checks that the request of an action route is posted by a Grafana user allowed
+// to change SCOM, that is an editor or admin of the organisation.
+func authorizeAction(req *backend.CallResourceRequest, action string) error {
+	if req.Method != http.MethodPost {
+		return &statusError{
+			status:  http.StatusMethodNotAllowed,
+			headers: map[string][]string{"Allow": {http.MethodPost}},
+			err:     fmt.Errorf("method %s not allowed, use POST", req.Method),
+		}
+	}
+
+	if user := req.PluginContext.User; user == nil || !actionRoles[user.Role] {
+		return &statusError{
+			status: http.StatusForbidden,
+			err:    fmt.Errorf("only editors and admins can %s", action),
+		}
+	}
+
+	return nil
+}
+
+// actionIds trims and validates the ids an action applies to.
+func actionIds(ids []string, property, kind string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("required property '%s' is missing or empty", property)
+	}
+
+	result := make([]string, len(ids))
+	for i, id := range ids {
+		result[i] = strings.TrimSpace(id)
+		if !guidPattern.MatchString(result[i]) {
+			return nil, fmt.Errorf("invalid %s: %q", kind, id)
+		}
+	}
+
+	return result, nil
+}
+
+// grafanaUser returns the login of the Grafana user a request was made by.
+func grafanaUser(pluginContext backend.PluginContext) string {
+	if pluginContext.User == nil || pluginContext.User.Login == "" {
+		return "unknown"
+	}
+
+	return pluginContext.User.Login
+}
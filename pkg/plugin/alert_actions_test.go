@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+func callResource(t *testing.T, ds *ScomDatasource, req *backend.CallResourceRequest) *backend.CallResourceResponse {
+	t.Helper()
+
+	var response *backend.CallResourceResponse
+	err := ds.CallResource(context.Background(), req, backend.CallResourceResponseSenderFunc(func(r *backend.CallResourceResponse) error {
+		response = r
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return response
+}
+
+func TestAlertActions(t *testing.T) {
+	var updates []models.AlertUpdate
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/OperationsManager/data/alertResolutionStates":
+			_, _ = w.Write([]byte(`[{"name": "Closed", "resolutionState": 255}]`))
+		case "/OperationsManager/data/alert/update":
+			var update models.AlertUpdate
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				t.Error(err)
+			}
+			updates = append(updates, update)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+
+	client.settings.ServerExtension = true
+
+	ds := &ScomDatasource{client: client}
+	pluginContext := backend.PluginContext{User: &backend.User{Login: "operator", Role: "Editor"}}
+	id := "6f7e8b3c-1d2a-4b5c-9e8f-0a1b2c3d4e5f"
+
+	response := callResource(t, ds, &backend.CallResourceRequest{
+		PluginContext: pluginContext,
+		Path:          "closeAlerts",
+		Method:        http.MethodPost,
+		Body:          []byte(`{"alertIds": ["` + id + `"], "comment": "Fixed"}`),
+	})
+	if response.Status != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", response.Status, response.Body)
+	}
+	if len(updates) != 1 || *updates[0].ResolutionState != 255 || updates[0].Comment != "Fixed" || updates[0].AlertIds[0] != id {
+		t.Errorf("unexpected updates %+v", updates)
+	}
+
+	for _, body := range []string{
+		`{"alertIds": ["not-a-guid"], "owner": "jdoe"}`,
+		`{"alertIds": []}`,
+		`{"alertIds": ["` + id + `"]}`,
+	} {
+		response := callResource(t, ds, &backend.CallResourceRequest{
+			PluginContext: pluginContext,
+			Path:          "setAlertOwner",
+			Method:        http.MethodPost,
+			Body:          []byte(body),
+		})
+		if response.Status != http.StatusBadRequest {
+			t.Errorf("%s: expected bad request, got %d", body, response.Status)
+		}
+	}
+
+	response = callResource(t, ds, &backend.CallResourceRequest{
+		PluginContext: pluginContext,
+		Path:          "setAlertResolutionState",
+		Method:        http.MethodPost,
+		Body:          []byte(`{"alertIds": ["` + id + `"], "resolutionState": 42}`),
+	})
+	if response.Status != http.StatusBadRequest {
+		t.Errorf("expected unknown resolution state to be rejected, got %d", response.Status)
+	}
+
+	response = callResource(t, ds, &backend.CallResourceRequest{PluginContext: pluginContext, Path: "closeAlerts", Method: http.MethodGet})
+	if response.Status != http.StatusMethodNotAllowed {
+		t.Errorf("expected GET to be rejected as not allowed, got %d", response.Status)
+	}
+
+	for _, user := range []*backend.User{nil, {Login: "viewer", Role: "Viewer"}} {
+		response = callResource(t, ds, &backend.CallResourceRequest{
+			PluginContext: backend.PluginContext{User: user},
+			Path:          "closeAlerts",
+			Method:        http.MethodPost,
+			Body:          []byte(`{"alertIds": ["` + id + `"]}`),
+		})
+		if response.Status != http.StatusForbidden {
+			t.Errorf("expected %+v to be forbidden, got %d", user, response.Status)
+		}
+	}
+
+	if len(updates) != 1 {
+		t.Errorf("expected rejected actions not to reach SCOM, got %d updates", len(updates))
+	}
+}
+
+func TestAlertActionsWithDefaultResolutionStates(t *testing.T) {
+	var updates int
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/OperationsManager/data/alertResolutionStates":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/OperationsManager/data/alert/update":
+			updates++
+		}
+	}))
+
+	ds := &ScomDatasource{client: client}
+	req := &backend.CallResourceRequest{
+		PluginContext: backend.PluginContext{User: &backend.User{Login: "operator", Role: "Editor"}},
+		Path:          "setAlertResolutionState",
+		Method:        http.MethodPost,
+		Body:          []byte(`{"alertIds": ["6f7e8b3c-1d2a-4b5c-9e8f-0a1b2c3d4e5f"], "resolutionState": 42}`),
+	}
+
+	if response := callResource(t, ds, req); response.Status != http.StatusBadRequest || updates != 0 {
+		t.Errorf("expected alert actions to need the server extension, got %d with %d updates", response.Status, updates)
+	}
+
+	client.settings.ServerExtension = true
+	if response := callResource(t, ds, req); response.Status != http.StatusOK {
+		t.Fatalf("expected a custom state to be accepted while only the defaults are known, got %d: %s", response.Status, response.Body)
+	}
+	if updates != 1 {
+		t.Errorf("expected one update, got %d", updates)
+	}
+}
@@ -0,0 +1,382 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+// queryAlerts fetches the alerts matched by the criteria of q, requesting columns on top of the
+// default ones. The time filter of q is added to the criteria.
+func (d *ScomDatasource) queryAlerts(ctx context.Context, q models.AlertQuery, timeRange backend.TimeRange, columns []string) (models.ScomAlert, error) {
+	criteria, err := alertCriteria(q, time.Now())
+	if err != nil {
+		return models.ScomAlert{}, err
+	}
+
+	if err := validateAlertTimeFilter(q.TimeFilter); err != nil {
+		return models.ScomAlert{}, err
+	}
+
+	return d.client.GetAlerts(ctx, withAlertTimeRange(criteria, q.TimeFilter, timeRange), columns)
+}
+
+// Layouts SCOM uses for alert timestamps. Timestamps without a zone are in UTC.
+var scomTimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"}
+
+// parseScomTime parses an alert timestamp. Empty values, as for unresolved alerts, return nil.
+func parseScomTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	for _, layout := range scomTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+
+	backend.Logger.Warn("Error parsing alert time", "value", value)
+
+	return nil
+}
+
+func validateAlertTimeFilter(filter string) error {
+	switch filter {
+	case "", models.AlertTimeFilterRaised, models.AlertTimeFilterModified:
+		return nil
+	}
+
+	return fmt.Errorf("unknown alert time filter: %s", filter)
+}
+
+// Alert columns holding timestamps, returned as time fields.
+var alertTimeColumns = map[string]bool{
+	"timeraised":                      true,
+	"lastmodified":                    true,
+	"timeresolved":                    true,
+	"timeadded":                       true,
+	"statelastmodified":               true,
+	"maintenancemodelastmodified":     true,
+	"timeresolutionstatelastmodified": true,
+}
+
+// normalizeAlertColumns lower cases and trims column names, dropping empty and duplicate ones.
+func normalizeAlertColumns(columns []string) []string {
+	var result []string
+	for _, column := range columns {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if column != "" {
+			result = appendUnique(result, column)
+		}
+	}
+
+	return result
+}
+
+// buildAlertColumnsFrame returns the alert id followed by the given columns, in order. Field names
+// are taken from the table columns SCOM returns and field types from the returned values. The
+// resolution state column is followed by the name of the state.
+func (d *ScomDatasource) buildAlertColumnsFrame(alerts models.ScomAlert, columns []string, resolutionStateNames map[int]string) data.Frames {
+	frame := data.NewFrame("data")
+
+	headers := make(map[string]models.TableColumn, len(alerts.TableColumns))
+	for _, column := range alerts.TableColumns {
+		headers[strings.ToLower(column.Field)] = column
+	}
+
+	ids := make([]string, len(alerts.Rows))
+	for i, alert := range alerts.Rows {
+		ids[i] = alert.ID
+	}
+	frame.Fields = append(frame.Fields, data.NewField("ID", nil, ids))
+
+	for _, column := range columns {
+		if column == "id" {
+			continue
+		}
+
+		name := column
+		header, ok := headers[column]
+		if ok && header.Header != "" {
+			name = header.Header
+		}
+
+		values := make([]interface{}, len(alerts.Rows))
+		for i, alert := range alerts.Rows {
+			values[i] = alert.Fields[column]
+		}
+
+		frame.Fields = append(frame.Fields, alertColumnField(name, column, header.Type, values))
+
+		if column == "resolutionstate" {
+			_, stateNames := resolutionStateFields(alerts, resolutionStateNames)
+			frame.Fields = append(frame.Fields, stateNames)
+		}
+	}
+
+	frame.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeTable})
+
+	return data.Frames{frame}
+}
+
+// alertColumnField builds a nullable field of the type matching the returned values. Time columns
+// become time fields, columns holding only numbers or booleans become numeric or boolean fields and
+// everything else is returned as text.
+func alertColumnField(name, column, columnType string, values []interface{}) *data.Field {
+	kind := ""
+	for _, value := range values {
+		if value == nil {
+			continue
+		}
+
+		var valueKind string
+		switch value.(type) {
+		case float64:
+			valueKind = "number"
+		case bool:
+			valueKind = "bool"
+		default:
+			valueKind = "string"
+		}
+
+		if kind == "" {
+			kind = valueKind
+		} else if kind != valueKind {
+			kind = "string"
+		}
+	}
+
+	if alertTimeColumns[column] || strings.EqualFold(columnType, "datetime") {
+		times := make([]*time.Time, len(values))
+		for i, value := range values {
+			if s, ok := value.(string); ok {
+				times[i] = parseScomTime(s)
+			}
+		}
+		return data.NewField(name, nil, times)
+	}
+
+	switch kind {
+	case "number":
+		numbers := make([]*float64, len(values))
+		for i, value := range values {
+			if n, ok := value.(float64); ok {
+				numbers[i] = &n
+			}
+		}
+		return data.NewField(name, nil, numbers)
+	case "bool":
+		bools := make([]*bool, len(values))
+		for i, value := range values {
+			if b, ok := value.(bool); ok {
+				bools[i] = &b
+			}
+		}
+		return data.NewField(name, nil, bools)
+	}
+
+	texts := make([]*string, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			texts[i] = &v
+		default:
+			text := fmt.Sprint(v)
+			texts[i] = &text
+		}
+	}
+
+	return data.NewField(name, nil, texts)
+}
+
+// Frame field names of the alert group by properties.
+var alertGroupByNames = map[string]string{
+	models.AlertGroupBySeverity:        "Severity",
+	models.AlertGroupByResolutionState: "Resolution state",
+	models.AlertGroupByObject:          "Object display name",
+	models.AlertGroupByClass:           "Class",
+	models.AlertGroupByName:            "Name",
+}
+
+func validateAlertGroupBy(groupBy []string) error {
+	if len(groupBy) == 0 {
+		return fmt.Errorf("required property 'groupBy' is missing or empty")
+	}
+
+	for _, property := range groupBy {
+		if _, ok := alertGroupByNames[property]; !ok {
+			return fmt.Errorf("unknown alert group by property: %s", property)
+		}
+	}
+
+	return nil
+}
+
+// alertGroupByValue returns the value of an alert for a group by property. Resolution states are
+// named by names.
+func alertGroupByValue(alert models.ScomAlertRow, property string, names map[int]string) string {
+	switch property {
+	case models.AlertGroupBySeverity:
+		return alert.Severity
+	case models.AlertGroupByResolutionState:
+		state, err := strconv.Atoi(string(alert.ResolutionState))
+		if err != nil {
+			return string(alert.ResolutionState)
+		}
+		return resolutionStateName(state, names)
+	case models.AlertGroupByObject:
+		return alert.MonitoringObject
+	case models.AlertGroupByClass:
+		return string(alert.MonitoringClassName)
+	case models.AlertGroupByName:
+		return alert.Name
+	}
+
+	return ""
+}
+
+// buildAlertAggregationFrame counts alerts per distinct combination of the groupBy properties.
+// Groups are ordered by descending count, then by their values.
+func (d *ScomDatasource) buildAlertAggregationFrame(alerts models.ScomAlert, groupBy []string, names map[int]string) data.Frames {
+	type alertGroup struct {
+		values []string
+		count  int64
+	}
+
+	var groups []*alertGroup
+	index := map[string]*alertGroup{}
+
+	for _, alert := range alerts.Rows {
+		values := make([]string, len(groupBy))
+		for i, property := range groupBy {
+			values[i] = alertGroupByValue(alert, property, names)
+		}
+
+		key := strings.Join(values, "\x00")
+		group, exists := index[key]
+		if !exists {
+			group = &alertGroup{values: values}
+			index[key] = group
+			groups = append(groups, group)
+		}
+		group.count++
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].count != groups[j].count {
+			return groups[i].count > groups[j].count
+		}
+		return strings.Join(groups[i].values, "\x00") < strings.Join(groups[j].values, "\x00")
+	})
+
+	frame := data.NewFrame("alertCounts")
+
+	for i, property := range groupBy {
+		values := make([]string, len(groups))
+		for j, group := range groups {
+			values[j] = group.values[i]
+		}
+		frame.Fields = append(frame.Fields, data.NewField(alertGroupByNames[property], nil, values))
+	}
+
+	counts := make([]int64, len(groups))
+	for i, group := range groups {
+		counts[i] = group.count
+	}
+	frame.Fields = append(frame.Fields, data.NewField("Count", nil, counts))
+
+	frame.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeTable})
+
+	return data.Frames{frame}
+}
+
+// Alert columns requested on top of the defaults for the class name of alerts, used by annotation
+// tags and to group alerts by class.
+var alertClassNameColumns = []string{"monitoringclassname"}
+
+// filterAlertsOverlapping keeps the alerts that were active during timeRange: raised before its end
+// and either unresolved or resolved after its start.
+func filterAlertsOverlapping(alerts models.ScomAlert, timeRange backend.TimeRange) models.ScomAlert {
+	rows := alerts.Rows[:0]
+	for _, alert := range alerts.Rows {
+		raised := parseScomTime(alert.TimeRaised)
+		if raised == nil || raised.After(timeRange.To) {
+			continue
+		}
+
+		if resolved := parseScomTime(alert.TimeResolved); resolved != nil && resolved.Before(timeRange.From) {
+			continue
+		}
+
+		rows = append(rows, alert)
+	}
+	alerts.Rows = rows
+
+	return alerts
+}
+
+// alertAnnotationTags returns the comma separated tags of an alert. Grafana splits the tags field
+// on commas, so commas within values are replaced.
+func alertAnnotationTags(alert models.ScomAlertRow) string {
+	var tags []string
+	for _, tag := range []struct{ key, value string }{
+		{"severity", alert.Severity},
+		{"object", alert.MonitoringObject},
+		{"class", string(alert.MonitoringClassName)},
+	} {
+		if tag.value == "" {
+			continue
+		}
+		tags = append(tags, tag.key+":"+strings.ReplaceAll(tag.value, ",", " "))
+	}
+
+	return strings.Join(tags, ",")
+}
+
+// buildAlertAnnotationsFrame returns a frame Grafana reads as annotations: an alert spans from the
+// time it was raised until it was resolved, or is a point in time while unresolved. Alerts without
+// a time raised are skipped.
+func (d *ScomDatasource) buildAlertAnnotationsFrame(alerts models.ScomAlert) data.Frames {
+	var (
+		ids    []string
+		times  []time.Time
+		ends   []*time.Time
+		titles []string
+		texts  []string
+		tags   []string
+	)
+
+	for _, alert := range alerts.Rows {
+		raised := parseScomTime(alert.TimeRaised)
+		if raised == nil {
+			continue
+		}
+
+		ids = append(ids, alert.ID)
+		times = append(times, *raised)
+		ends = append(ends, parseScomTime(alert.TimeResolved))
+		titles = append(titles, alert.Name)
+		texts = append(texts, alert.Description)
+		tags = append(tags, alertAnnotationTags(alert))
+	}
+
+	frame := data.NewFrame("annotations",
+		data.NewField("id", nil, ids),
+		data.NewField("time", nil, times),
+		data.NewField("timeEnd", nil, ends),
+		data.NewField("title", nil, titles),
+		data.NewField("text", nil, texts),
+		data.NewField("tags", nil, tags),
+	)
+
+	return data.Frames{frame}
+}
@@ -0,0 +1,178 @@
+package plugin
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+func TestParseScomTime(t *testing.T) {
+	want := time.Date(2024, 1, 15, 10, 23, 45, 123000000, time.UTC)
+
+	for _, value := range []string{"2024-01-15T10:23:45.123Z", "2024-01-15T10:23:45.123"} {
+		if got := parseScomTime(value); got == nil || !got.Equal(want) {
+			t.Errorf("%s: got %v", value, got)
+		}
+	}
+
+	if got := parseScomTime(""); got != nil {
+		t.Errorf("expected nil for empty value, got %v", got)
+	}
+}
+
+func TestBuildAlertColumnsFrame(t *testing.T) {
+	var alerts models.ScomAlert
+	err := json.Unmarshal([]byte(`{
+		"tableColumns": [
+			{"field": "owner", "header": "Owner", "type": "string"},
+			{"field": "resolutionstate", "header": "Resolution State"},
+			{"field": "timeraised", "header": "Time Raised"}
+		],
+		"rows": [
+			{"id": "1", "owner": "CONTOSO\\jdoe", "resolutionState": 0, "timeraised": "2024-01-15T10:23:45Z", "customfield1": null},
+			{"id": "2", "owner": null, "resolutionState": 255, "timeraised": "2024-01-16T10:23:45Z"}
+		]
+	}`), &alerts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if alerts.Rows[1].ResolutionState != "255" {
+		t.Errorf("expected numeric resolution state to decode as text, got %q", alerts.Rows[1].ResolutionState)
+	}
+
+	ds := ScomDatasource{}
+	frame := ds.buildAlertColumnsFrame(alerts, normalizeAlertColumns([]string{"Owner", "ResolutionState", "TimeRaised", "CustomField1"}), defaultResolutionStateNames)[0]
+
+	var names []string
+	for _, field := range frame.Fields {
+		names = append(names, field.Name)
+	}
+	want := []string{"ID", "Owner", "Resolution State", "Resolution state name", "Time Raised", "customfield1"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("got fields %v, want %v", names, want)
+	}
+
+	if frame.Fields[2].Type() != data.FieldTypeNullableFloat64 {
+		t.Errorf("expected numeric resolution state, got %s", frame.Fields[2].Type())
+	}
+	if got, _ := frame.Fields[3].ConcreteAt(1); got != "Closed" {
+		t.Errorf("expected resolution state name Closed, got %v", got)
+	}
+	if frame.Fields[4].Type() != data.FieldTypeNullableTime {
+		t.Errorf("expected time raised as time, got %s", frame.Fields[4].Type())
+	}
+	if v, ok := frame.Fields[1].ConcreteAt(1); ok {
+		t.Errorf("expected null owner, got %v", v)
+	}
+}
+
+func TestBuildAlertAggregationFrame(t *testing.T) {
+	alerts := models.ScomAlert{Rows: []models.ScomAlertRow{
+		{Severity: "Warning", MonitoringObject: "web01"},
+		{Severity: "Error", MonitoringObject: "sql01"},
+		{Severity: "Error", MonitoringObject: "web01"},
+		{Severity: "Error", MonitoringObject: "web01"},
+	}}
+
+	ds := ScomDatasource{}
+	frame := ds.buildAlertAggregationFrame(alerts, []string{models.AlertGroupBySeverity, models.AlertGroupByObject}, nil)[0]
+
+	if frame.Rows() != 3 {
+		t.Fatalf("expected 3 groups, got %d", frame.Rows())
+	}
+
+	want := [][]interface{}{
+		{"Error", "web01", int64(2)},
+		{"Error", "sql01", int64(1)},
+		{"Warning", "web01", int64(1)},
+	}
+	for i, row := range want {
+		for j, value := range row {
+			if got := frame.Fields[j].At(i); got != value {
+				t.Errorf("row %d field %s: got %v, want %v", i, frame.Fields[j].Name, got, value)
+			}
+		}
+	}
+}
+
+func TestBuildAlertAggregationFrameNamesClassesAndResolutionStates(t *testing.T) {
+	alerts := models.ScomAlert{Rows: []models.ScomAlertRow{
+		{MonitoringClassId: "ea99500d-8d52-fc52-b5a5-10dcd1e9d2bd", MonitoringClassName: "Windows Computer", ResolutionState: "0"},
+		{MonitoringClassId: "ea99500d-8d52-fc52-b5a5-10dcd1e9d2bd", MonitoringClassName: "Windows Computer", ResolutionState: "0"},
+		{MonitoringClassId: "0c2d2b6c-7a4f-4b0a-9c3e-2f5a3c1d9e11", MonitoringClassName: "Logical Disk", ResolutionState: "42"},
+	}}
+
+	ds := ScomDatasource{}
+	frame := ds.buildAlertAggregationFrame(alerts, []string{models.AlertGroupByClass, models.AlertGroupByResolutionState}, defaultResolutionStateNames)[0]
+
+	want := [][]interface{}{
+		{"Windows Computer", "New", int64(2)},
+		{"Logical Disk", "42", int64(1)},
+	}
+	if frame.Fields[0].Name != "Class" {
+		t.Errorf("unexpected class field name %q", frame.Fields[0].Name)
+	}
+	for i, row := range want {
+		for j, value := range row {
+			if got := frame.Fields[j].At(i); got != value {
+				t.Errorf("row %d field %s: got %v, want %v", i, frame.Fields[j].Name, got, value)
+			}
+		}
+	}
+}
+
+func TestParseAlertAggregationQuery(t *testing.T) {
+	q, err := ParseQuery([]byte(`{"type": "alertAggregation", "criteria": "Severity = 2", "groupBy": ["severity"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aggregation, ok := q.(models.AlertAggregationQuery)
+	if !ok {
+		t.Fatalf("unexpected query type %T", q)
+	}
+	if aggregation.Criteria != "Severity = 2" || len(aggregation.GroupBy) != 1 {
+		t.Errorf("unexpected query %+v", aggregation)
+	}
+}
+
+func TestBuildAlertAnnotationsFrame(t *testing.T) {
+	alerts := models.ScomAlert{Rows: []models.ScomAlertRow{
+		{ID: "before", TimeRaised: "2024-01-01T00:00:00Z", TimeResolved: "2024-01-02T00:00:00Z"},
+		{ID: "open", Name: "Disk full", Description: "C: is full", Severity: "Error", MonitoringObject: "web01, c:", MonitoringClassName: "Logical Disk", TimeRaised: "2024-01-01T00:00:00Z"},
+		{ID: "resolved", Severity: "Warning", TimeRaised: "2024-01-10T06:00:00Z", TimeResolved: "2024-01-10T08:00:00Z"},
+		{ID: "after", TimeRaised: "2024-01-12T00:00:00Z"},
+	}}
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC),
+	}
+
+	ds := ScomDatasource{}
+	frame := ds.buildAlertAnnotationsFrame(filterAlertsOverlapping(alerts, timeRange))[0]
+
+	if frame.Rows() != 2 {
+		t.Fatalf("expected 2 annotations, got %d", frame.Rows())
+	}
+
+	if got := frame.Fields[0].At(0); got != "open" {
+		t.Errorf("unexpected first annotation %v", got)
+	}
+	if _, ok := frame.Fields[2].ConcreteAt(0); ok {
+		t.Errorf("expected no end time for an unresolved alert")
+	}
+	if got := frame.Fields[5].At(0); got != "severity:Error,object:web01  c:,class:Logical Disk" {
+		t.Errorf("unexpected tags %v", got)
+	}
+
+	end, ok := frame.Fields[2].ConcreteAt(1)
+	if !ok || !end.(time.Time).Equal(time.Date(2024, 1, 10, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected end time %v", end)
+	}
+}
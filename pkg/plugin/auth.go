@@ -2,12 +2,16 @@ package plugin
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+
+	"github.com/Azure/go-ntlmssp"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
 )
 
 type AuthTokens struct {
@@ -16,19 +20,25 @@ type AuthTokens struct {
 	AuthToken string
 }
 
-func Authenticate(baseUrl string, userName string, password string, IsSkipTlsVerifyCheck bool) (AuthTokens, error) {
+// Authenticate retrieves session tokens from SCOM using the authentication mode configured in settings.
+func Authenticate(ctx context.Context, settings *models.PluginSettings) (AuthTokens, error) {
 	// Get tokens.
 	result := AuthTokens{}
 
-	bodyraw := fmt.Sprintf("AuthenticationMode:%s:%s", userName, password)
+	// The authentication body tells SCOM which mode is used. For Windows authentication the
+	// credentials are carried by the NTLM/Negotiate handshake instead of the body.
+	bodyraw := fmt.Sprintf("AuthenticationMode:%s:%s", settings.UserName, settings.Secrets.Password)
+	if settings.AuthMode == models.AuthModeWindows {
+		bodyraw = "Windows"
+	}
 	bytesAuthBody := []byte(bodyraw)
 	scomAuthNBodyString := base64.StdEncoding.EncodeToString(bytesAuthBody)
 	scomAuthNBody := fmt.Sprintf("'%s'", scomAuthNBodyString)
 
-	pair := fmt.Sprintf("%s:%s", userName, password)
+	pair := fmt.Sprintf("%s:%s", settings.UserName, settings.Secrets.Password)
 	basicToken := base64.StdEncoding.EncodeToString([]byte(pair))
 
-	scomAuthNUri := baseUrl + "/OperationsManager/authenticate"
+	scomAuthNUri := settings.Url + "/OperationsManager/authenticate"
 	scomHeader := map[string]string{
 		"Content-Type":  "application/json; charset=utf-8",
 		"Authorization": "Basic " + basicToken,
@@ -36,14 +46,15 @@ func Authenticate(baseUrl string, userName string, password string, IsSkipTlsVer
 
 	// Create an HTTP client with custom TLS configuration to disable SSL certificate validation.
 	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: IsSkipTlsVerifyCheck},
-		},
+		Timeout: authenticateTimeout,
+		Transport: authTransport(settings.AuthMode, &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: settings.IsSkipTlsVerifyCheck},
+		}),
 	}
 
 	body := bytes.NewBufferString(scomAuthNBody)
 
-	req, err := http.NewRequest("POST", scomAuthNUri, body)
+	req, err := http.NewRequestWithContext(ctx, "POST", scomAuthNUri, body)
 	if err != nil {
 		return result, err
 	}
@@ -81,9 +92,25 @@ func Authenticate(baseUrl string, userName string, password string, IsSkipTlsVer
 		return result, errors.New("scomSessionID or scomCSRFToken is empty")
 	}
 
-	result.AuthToken = basicToken
+	// Windows sessions are carried by the session cookie alone, so later requests only send the
+	// basic credentials in basic mode.
+	if settings.AuthMode != models.AuthModeWindows {
+		result.AuthToken = basicToken
+	}
 	result.CSRFToken = scomCSRFToken
 	result.SessionID = scomSessionID
 
 	return result, nil
 }
+
+// authTransport wraps next with the round tripper required by the authentication mode.
+// In Windows mode the basic credentials set on the authenticate request are converted into
+// an NTLM/Negotiate handshake whenever the server challenges the request. It is only used
+// for /authenticate; data requests are authorized by the resulting session cookie.
+func authTransport(mode string, next http.RoundTripper) http.RoundTripper {
+	if mode == models.AuthModeWindows {
+		return ntlmssp.Negotiator{RoundTripper: next}
+	}
+
+	return next
+}
@@ -0,0 +1,108 @@
+package plugin
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+// ntlmChallenge builds a minimal NTLM CHALLENGE_MESSAGE (type 2) without target name or info.
+func ntlmChallenge() []byte {
+	msg := make([]byte, 48)
+	copy(msg, "NTLMSSP\x00")
+	binary.LittleEndian.PutUint32(msg[8:], 2)
+	// NTLMSSP_NEGOTIATE_UNICODE | NTLMSSP_NEGOTIATE_NTLM
+	binary.LittleEndian.PutUint32(msg[20:], 0x00000201)
+	copy(msg[24:], "12345678")
+	return msg
+}
+
+// ntlmMessageType decodes the NTLM message type carried in an Authorization header.
+func ntlmMessageType(header string) uint32 {
+	encoded, ok := strings.CutPrefix(header, "NTLM ")
+	if !ok {
+		return 0
+	}
+	msg, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(msg) < 12 {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(msg[8:])
+}
+
+func newNTLMStubServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch ntlmMessageType(r.Header.Get("Authorization")) {
+		case 1:
+			w.Header().Set("WWW-Authenticate", "NTLM "+base64.StdEncoding.EncodeToString(ntlmChallenge()))
+			w.WriteHeader(http.StatusUnauthorized)
+		case 3:
+			if r.URL.Path != "/OperationsManager/authenticate" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "SCOMSessionId", Value: "session"})
+			http.SetCookie(w, &http.Cookie{Name: "SCOM-CSRF-TOKEN", Value: "csrf%2Btoken"})
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.Header().Set("WWW-Authenticate", "NTLM")
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+}
+
+func TestAuthenticateWindows(t *testing.T) {
+	server := newNTLMStubServer()
+	defer server.Close()
+
+	tokens, err := Authenticate(context.Background(), &models.PluginSettings{
+		Url:      server.URL,
+		UserName: `CONTOSO\administrator`,
+		AuthMode: models.AuthModeWindows,
+		Secrets:  &models.SecretPluginSettings{Password: "secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tokens.SessionID != "SCOMSessionId=session" {
+		t.Errorf("unexpected session id: %s", tokens.SessionID)
+	}
+	if tokens.CSRFToken != "csrf+token" {
+		t.Errorf("unexpected csrf token: %s", tokens.CSRFToken)
+	}
+	if tokens.AuthToken != "" {
+		t.Errorf("expected no basic token for a windows session, got %s", tokens.AuthToken)
+	}
+
+	// Data requests carry the session cookie only, without renegotiating NTLM.
+	req := httptest.NewRequest(http.MethodGet, server.URL+"/OperationsManager/data/alert", nil)
+	setAuthHeaders(req, tokens)
+	if header := req.Header.Get("Authorization"); header != "" {
+		t.Errorf("unexpected authorization header on data request: %s", header)
+	}
+	if cookie := req.Header.Get("Cookie"); cookie != tokens.SessionID {
+		t.Errorf("unexpected session cookie: %s", cookie)
+	}
+}
+
+func TestAuthenticateBasicRejectsNTLMChallenge(t *testing.T) {
+	server := newNTLMStubServer()
+	defer server.Close()
+
+	_, err := Authenticate(context.Background(), &models.PluginSettings{
+		Url:      server.URL,
+		UserName: `CONTOSO\administrator`,
+		AuthMode: models.AuthModeBasic,
+		Secrets:  &models.SecretPluginSettings{Password: "secret"},
+	})
+	if err == nil {
+		t.Fatal("expected basic authentication to fail against an NTLM-only server")
+	}
+}
@@ -0,0 +1,250 @@
+package plugin
+
+import (
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+// Availability buckets time is counted in, in frame field order.
+const (
+	availabilitySuccess     = "Success"
+	availabilityWarning     = "Warning"
+	availabilityError       = "Error"
+	availabilityUnmonitored = "Unmonitored"
+	availabilityMaintenance = "Maintenance"
+)
+
+var availabilityBuckets = []string{availabilitySuccess, availabilityWarning, availabilityError, availabilityUnmonitored, availabilityMaintenance}
+
+// availabilityBucket returns the bucket time in a health state is counted in. States other than
+// success, warning and error, such as uninitialized or not monitored, count as unmonitored.
+func availabilityBucket(state string) string {
+	switch healthStateCode(state) {
+	case healthStateSuccess:
+		return availabilitySuccess
+	case healthStateWarning:
+		return availabilityWarning
+	case healthStateError:
+		return availabilityError
+	case healthStateMaintenance:
+		return availabilityMaintenance
+	}
+
+	return availabilityUnmonitored
+}
+
+// availability is the time spent in every availability bucket.
+type availability map[string]time.Duration
+
+func (a availability) add(other availability) {
+	for bucket, duration := range other {
+		a[bucket] += duration
+	}
+}
+
+func (a availability) total() time.Duration {
+	var total time.Duration
+	for _, duration := range a {
+		total += duration
+	}
+
+	return total
+}
+
+// percent returns the share of the total time spent in bucket.
+func (a availability) percent(bucket string) float64 {
+	total := a.total()
+	if total == 0 {
+		return 0
+	}
+
+	return float64(a[bucket]) / float64(total) * 100
+}
+
+// available returns the share of time in success or warning. Maintenance counts as downtime,
+// unless it is excluded from the time the share is taken of.
+func (a availability) available(excludeMaintenance bool) float64 {
+	total := a.total()
+	if excludeMaintenance {
+		total -= a[availabilityMaintenance]
+	}
+	if total == 0 {
+		return 0
+	}
+
+	return float64(a[availabilitySuccess]+a[availabilityWarning]) / float64(total) * 100
+}
+
+// timeSpan is a period of time, used for maintenance windows.
+type timeSpan struct {
+	Start, End time.Time
+}
+
+// maintenanceSpans returns the maintenance windows of every object as sorted, non overlapping
+// spans. Windows without any end time last until end.
+func maintenanceSpans(windows []models.MaintenanceWindow, end time.Time) map[string][]timeSpan {
+	spans := map[string][]timeSpan{}
+	for _, window := range windows {
+		start := parseScomTime(window.StartTime)
+		if start == nil {
+			continue
+		}
+
+		span := timeSpan{Start: *start, End: end}
+		if windowEnd := maintenanceWindowEnd(window); windowEnd != nil {
+			span.End = *windowEnd
+		}
+
+		spans[window.Object.ID] = append(spans[window.Object.ID], span)
+	}
+
+	for id, objectSpans := range spans {
+		sort.Slice(objectSpans, func(i, j int) bool {
+			return objectSpans[i].Start.Before(objectSpans[j].Start)
+		})
+
+		merged := objectSpans[:1]
+		for _, span := range objectSpans[1:] {
+			last := &merged[len(merged)-1]
+			if span.Start.After(last.End) {
+				merged = append(merged, span)
+			} else if span.End.After(last.End) {
+				last.End = span.End
+			}
+		}
+		spans[id] = merged
+	}
+
+	return spans
+}
+
+// overlap returns how long span overlaps the period from start to end.
+func overlap(span timeSpan, start, end time.Time) time.Duration {
+	if span.Start.After(start) {
+		start = span.Start
+	}
+	if span.End.Before(end) {
+		end = span.End
+	}
+	if !end.After(start) {
+		return 0
+	}
+
+	return end.Sub(start)
+}
+
+// objectAvailability counts the time of every state interval in its bucket, except the time the
+// object was in maintenance, which is counted as maintenance.
+func objectAvailability(intervals []stateInterval, maintenance []timeSpan) availability {
+	a := availability{}
+	for _, interval := range intervals {
+		var inMaintenance time.Duration
+		for _, span := range maintenance {
+			inMaintenance += overlap(span, interval.Start, interval.End)
+		}
+
+		a[availabilityMaintenance] += inMaintenance
+		a[availabilityBucket(interval.State)] += interval.End.Sub(interval.Start) - inMaintenance
+	}
+
+	return a
+}
+
+// buildAvailabilityFrames returns the availability of every object, followed by the availability of
+// every group the objects were selected through when there are any. Group availability is the
+// time weighted availability of the members.
+func (d *ScomDatasource) buildAvailabilityFrames(series []stateSeries, windows []models.MaintenanceWindow, end time.Time, excludeMaintenance bool) data.Frames {
+	spans := maintenanceSpans(windows, end)
+
+	var (
+		objectIds    []string
+		objectNames  []string
+		groups       []string
+		classes      []string
+		objectValues []availability
+		groupNames   []string
+		groupCounts  []int64
+		groupValues  []availability
+		groupIndex   = map[string]int{}
+	)
+
+	for _, entry := range series {
+		a := objectAvailability(entry.Intervals, spans[entry.Object.ID])
+
+		objectIds = append(objectIds, entry.Object.ID)
+		objectNames = append(objectNames, entry.Object.DisplayName)
+		groups = append(groups, scopeGroups(entry.Object.Scope))
+		classes = append(classes, scopeClasses(entry.Object.Scope))
+		objectValues = append(objectValues, a)
+
+		for _, group := range entry.Object.Scope.Groups {
+			i, exists := groupIndex[group]
+			if !exists {
+				i = len(groupNames)
+				groupIndex[group] = i
+				groupNames = append(groupNames, group)
+				groupCounts = append(groupCounts, 0)
+				groupValues = append(groupValues, availability{})
+			}
+
+			groupCounts[i]++
+			groupValues[i].add(a)
+		}
+	}
+
+	objects := data.NewFrame("availability",
+		data.NewField("Object id", nil, objectIds),
+		data.NewField("Object display name", nil, objectNames),
+		data.NewField("Group", nil, groups),
+		data.NewField("Class", nil, classes),
+	)
+	objects.Fields = append(objects.Fields, availabilityFields(objectValues, excludeMaintenance)...)
+	objects.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeTable})
+
+	frames := data.Frames{objects}
+
+	if len(groupNames) > 0 {
+		groupFrame := data.NewFrame("groupAvailability",
+			data.NewField("Group", nil, groupNames),
+			data.NewField("Objects", nil, groupCounts),
+		)
+		groupFrame.Fields = append(groupFrame.Fields, availabilityFields(groupValues, excludeMaintenance)...)
+		groupFrame.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeTable})
+
+		frames = append(frames, groupFrame)
+	}
+
+	return frames
+}
+
+// availabilityFields returns the percentage of time in every bucket and the availability.
+func availabilityFields(values []availability, excludeMaintenance bool) []*data.Field {
+	var fields []*data.Field
+
+	for _, bucket := range availabilityBuckets {
+		percents := make([]float64, len(values))
+		for i, a := range values {
+			percents[i] = a.percent(bucket)
+		}
+		fields = append(fields, percentField(bucket+" %", percents))
+	}
+
+	available := make([]float64, len(values))
+	for i, a := range values {
+		available[i] = a.available(excludeMaintenance)
+	}
+
+	return append(fields, percentField("Availability %", available))
+}
+
+func percentField(name string, values []float64) *data.Field {
+	low, high := data.ConfFloat64(0), data.ConfFloat64(100)
+
+	field := data.NewField(name, nil, values)
+	field.SetConfig(&data.FieldConfig{Unit: "percent", Min: &low, Max: &high})
+
+	return field
+}
@@ -0,0 +1,70 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+func TestBuildAvailabilityFrames(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2024, 1, 10, hour, 0, 0, 0, time.UTC)
+	}
+
+	web := models.MonitoringObject{ID: "web", DisplayName: "web01", Scope: models.ObjectScope{Groups: []string{"Shop"}}}
+	sql := models.MonitoringObject{ID: "sql", DisplayName: "sql01", Scope: models.ObjectScope{Groups: []string{"Shop"}}}
+	series := []stateSeries{
+		{Object: web, Intervals: []stateInterval{
+			{State: "Success", Start: at(0), End: at(6)},
+			{State: "Error", Start: at(6), End: at(10)},
+		}},
+		{Object: sql, Intervals: []stateInterval{{State: "Success", Start: at(0), End: at(10)}}},
+	}
+	// Two overlapping windows cover 6:00-8:00 of the error period of web01. Excluding them leaves
+	// 6 of 8 hours available for web01 and 16 of 18 hours for the group.
+	windows := []models.MaintenanceWindow{
+		{Object: web, StartTime: "2024-01-10T06:00:00Z", EndTime: "2024-01-10T07:00:00Z"},
+		{Object: web, StartTime: "2024-01-10T06:30:00Z", EndTime: "2024-01-10T08:00:00Z"},
+	}
+
+	ds := ScomDatasource{}
+
+	for _, tc := range []struct {
+		excludeMaintenance bool
+		objectAvailable    float64
+		groupAvailable     float64
+	}{
+		{false, 60, 80},
+		{true, 75, 16.0 / 18.0 * 100},
+	} {
+		frames := ds.buildAvailabilityFrames(series, windows, at(10), tc.excludeMaintenance)
+		if len(frames) != 2 {
+			t.Fatalf("expected object and group frames, got %d", len(frames))
+		}
+
+		objects, groups := frames[0], frames[1]
+		fields := map[string]int{}
+		for i, field := range objects.Fields {
+			fields[field.Name] = i
+		}
+
+		if got := objects.Fields[fields["Error %"]].At(0).(float64); got != 20 {
+			t.Errorf("expected 20%% error, got %v", got)
+		}
+		if got := objects.Fields[fields["Maintenance %"]].At(0).(float64); got != 20 {
+			t.Errorf("expected 20%% maintenance, got %v", got)
+		}
+		if got := objects.Fields[fields["Availability %"]].At(0).(float64); math.Abs(got-tc.objectAvailable) > 1e-9 {
+			t.Errorf("exclude %v: expected %v%% available, got %v", tc.excludeMaintenance, tc.objectAvailable, got)
+		}
+
+		if got := groups.Fields[1].At(0); got != int64(2) {
+			t.Errorf("expected 2 group members, got %v", got)
+		}
+		if got := groups.Fields[len(groups.Fields)-1].At(0).(float64); math.Abs(got-tc.groupAvailable) > 1e-9 {
+			t.Errorf("exclude %v: expected group %v%% available, got %v", tc.excludeMaintenance, tc.groupAvailable, got)
+		}
+	}
+}
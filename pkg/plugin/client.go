@@ -2,31 +2,38 @@ package plugin
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"sort"
-	"sync"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
 	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
 )
 
+// SCOM grooms performance data from the operational database, which the performance endpoint
+// reads, after at most 30 days.
+const maxPerformanceRetention = 30 * 24 * time.Hour
+
 // Custom API Client
 type ScomClient struct {
 	settings   *models.PluginSettings
 	httpClient *http.Client
-	tokens     AuthTokens
-	mu         sync.Mutex
+	tokens     *TokenManager
+	pool       *WorkerPool
 }
 
 // NewScomClient initializes a scom client with authentication middleware
-func NewScomClient(httpOptions httpclient.Options, settings *models.PluginSettings) (*ScomClient, error) {
+func NewScomClient(ctx context.Context, httpOptions httpclient.Options, settings *models.PluginSettings) (*ScomClient, error) {
 	//Authenticate
-	tokens, err := Authenticate(settings.Url, settings.UserName, settings.Secrets.Password, settings.IsSkipTlsVerifyCheck)
+	tokens, err := NewTokenManager(ctx, settings)
 	if err != nil {
 		return nil, fmt.Errorf("failed to authenticate: %v", err)
 	}
@@ -34,6 +41,7 @@ func NewScomClient(httpOptions httpclient.Options, settings *models.PluginSettin
 	client := &ScomClient{
 		settings: settings,
 		tokens:   tokens,
+		pool:     NewWorkerPool(settings.MaxConcurrency),
 	}
 
 	httpOptions.ConfigureTLSConfig = func(opts httpclient.Options, tlsConfig *tls.Config) {
@@ -45,6 +53,7 @@ func NewScomClient(httpOptions httpclient.Options, settings *models.PluginSettin
 
 	httpClient, err := httpclient.New(httpOptions)
 	if err != nil {
+		tokens.Stop()
 		return nil, fmt.Errorf("failed to create HTTP client: %v", err)
 	}
 
@@ -53,6 +62,11 @@ func NewScomClient(httpOptions httpclient.Options, settings *models.PluginSettin
 	return client, nil
 }
 
+// Close stops the background session refresh.
+func (c *ScomClient) Close() {
+	c.tokens.Stop()
+}
+
 func (c *ScomClient) AuthMiddleware() httpclient.MiddlewareFunc {
 	return httpclient.MiddlewareFunc(func(opts httpclient.Options, next http.RoundTripper) http.RoundTripper {
 		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
@@ -66,17 +80,8 @@ func (c *ScomClient) AuthMiddleware() httpclient.MiddlewareFunc {
 				req.Body = io.NopCloser(bytes.NewReader(bodyBytes)) // Restore body for first request
 			}
 
-			// Lock while accessing tokens
-			c.mu.Lock()
-			authToken := c.tokens.AuthToken
-			csrfToken := c.tokens.CSRFToken
-			sessionID := c.tokens.SessionID
-			c.mu.Unlock()
-
-			req.Header.Set("Authorization", "Basic "+authToken)
-			req.Header.Set("SCOM-CSRF-TOKEN", csrfToken)
-			req.Header.Set("Cookie", sessionID)
-			req.Header.Set("Content-Type", "application/json")
+			tokens := c.tokens.Tokens()
+			setAuthHeaders(req, tokens)
 
 			resp, err := next.RoundTrip(req)
 
@@ -84,28 +89,23 @@ func (c *ScomClient) AuthMiddleware() httpclient.MiddlewareFunc {
 				return resp, err
 			}
 
-			if resp.StatusCode == 440 {
-				c.mu.Lock()
-				defer c.mu.Unlock()
+			if c.tokens.SessionExpired(resp, tokens) {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
 
-				if authToken == c.tokens.AuthToken {
-					newTokens, err := Authenticate(c.settings.Url, c.settings.UserName, c.settings.Secrets.Password, c.settings.IsSkipTlsVerifyCheck)
-					if err != nil {
-						return resp, fmt.Errorf("failed to refresh authentication tokens: %v", err)
-					}
-					c.tokens = newTokens
+				// Concurrent requests that hit the same expired session share a single refresh.
+				newTokens, err := c.tokens.Refresh(req.Context(), tokens)
+				if err != nil {
+					return nil, fmt.Errorf("failed to refresh authentication tokens: %v", err)
 				}
 
-				// Retry request with new tokens
+				// Retry request with new tokens, once: a retry that is rejected again is returned as is.
 				req2 := req.Clone(req.Context())
 				if len(bodyBytes) > 0 {
 					req2.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 				}
 
-				req2.Header.Set("Authorization", "Basic "+c.tokens.AuthToken)
-				req2.Header.Set("SCOM-CSRF-TOKEN", c.tokens.CSRFToken)
-				req2.Header.Set("Cookie", c.tokens.SessionID)
-				req2.Header.Set("Content-Type", "application/json")
+				setAuthHeaders(req2, newTokens)
 
 				return next.RoundTrip(req2)
 			}
@@ -115,9 +115,20 @@ func (c *ScomClient) AuthMiddleware() httpclient.MiddlewareFunc {
 	})
 }
 
-func requestToType[T any](client *ScomClient, method, endpoint string, body interface{}) (T, error) {
+// setAuthHeaders authorizes a request with the session tokens. Windows sessions have no basic
+// token and rely on the session cookie alone.
+func setAuthHeaders(req *http.Request, tokens AuthTokens) {
+	if tokens.AuthToken != "" {
+		req.Header.Set("Authorization", "Basic "+tokens.AuthToken)
+	}
+	req.Header.Set("SCOM-CSRF-TOKEN", tokens.CSRFToken)
+	req.Header.Set("Cookie", tokens.SessionID)
+	req.Header.Set("Content-Type", "application/json")
+}
+
+func requestToType[T any](ctx context.Context, client *ScomClient, method, endpoint string, body interface{}) (T, error) {
 
-	resp, err := client.request(method, endpoint, body)
+	resp, err := client.request(ctx, method, endpoint, body)
 	if err != nil {
 		return *new(T), fmt.Errorf("failed to send request: %w", err)
 	}
@@ -143,8 +154,23 @@ func requestToType[T any](client *ScomClient, method, endpoint string, body inte
 	return responseBody, nil
 }
 
+// errServerExtensionDisabled is returned for server extension endpoints when the datasource does not
+// enable the extension.
+var errServerExtensionDisabled = errors.New("this feature needs the SCOM server extension, enable it in the datasource settings")
+
+// extensionRequest sends a request to an endpoint that is not part of the SCOM REST API. These are
+// served by an extension installed on the web console server, listed in the README, and are only
+// called when the datasource enables the extension.
+func extensionRequest[T any](ctx context.Context, client *ScomClient, method, endpoint string, body interface{}) (T, error) {
+	if !client.settings.ServerExtension {
+		return *new(T), errServerExtensionDisabled
+	}
+
+	return requestToType[T](ctx, client, method, endpoint, body)
+}
+
 // Request performs an HTTP request and returns the response content as a generic type.
-func (c *ScomClient) request(method, endpoint string, body interface{}) (*http.Response, error) {
+func (c *ScomClient) request(ctx context.Context, method, endpoint string, body interface{}) (*http.Response, error) {
 	var reqBody io.Reader
 	if body != nil {
 		// Marshal the body to JSON
@@ -156,7 +182,7 @@ func (c *ScomClient) request(method, endpoint string, body interface{}) (*http.R
 	}
 
 	// Create the HTTP request
-	req, err := http.NewRequest(method, c.settings.Url+endpoint, reqBody)
+	req, err := http.NewRequestWithContext(ctx, method, c.settings.Url+endpoint, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -171,135 +197,262 @@ func (c *ScomClient) request(method, endpoint string, body interface{}) (*http.R
 }
 
 // https://learn.microsoft.com/en-us/rest/api/operationsmanager/data/retrieves-alert-data?tabs=HTTP
-func (c *ScomClient) GetAlerts(criteria string) (models.ScomAlert, error) {
-	// TODO: displayColumns does not include monitoringclassid
+// The default alert columns are always requested, extended with the given columns.
+func (c *ScomClient) GetAlerts(ctx context.Context, criteria string, columns []string) (models.ScomAlert, error) {
 	body := map[string]interface{}{
 		"criteria":       criteria,
-		"displayColumns": []string{"severity", "monitoringobjectdisplayname", "name", "age", "repeatcount", "description", "monitoringobjectid", "monitoringclassid"},
+		"displayColumns": appendUnique(append([]string(nil), models.DefaultAlertColumns...), normalizeAlertColumns(columns)...),
 		"classId":        "",
 	}
 
-	result, err := requestToType[models.ScomAlert](c, "POST", "/OperationsManager/data/alert", body)
+	result, err := requestToType[models.ScomAlert](ctx, c, "POST", "/OperationsManager/data/alert", body)
 	if err != nil {
-		return result, fmt.Errorf("")
+		return result, fmt.Errorf("failed to get alerts: %w", err)
 	}
 	return result, err
 }
 
-func (c *ScomClient) GetHealthStateForObjects(objects []models.MonitoringObject) ([]models.MonitoringDataResponse, error) {
+// GetResolutionStates returns the alert resolution states defined in the management group.
+func (c *ScomClient) GetResolutionStates(ctx context.Context) ([]models.ResolutionState, error) {
+	states, err := requestToType[[]models.ResolutionState](ctx, c, "GET", "/OperationsManager/data/alertResolutionStates", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resolution states: %w", err)
+	}
+
+	return states, nil
+}
+
+// UpdateAlerts applies an update to every alert of update.AlertIds.
+func (c *ScomClient) UpdateAlerts(ctx context.Context, update models.AlertUpdate) error {
+	_, err := extensionRequest[json.RawMessage](ctx, c, "POST", "/OperationsManager/data/alert/update", update)
+	if err != nil {
+		return fmt.Errorf("failed to update alerts: %w", err)
+	}
+
+	return nil
+}
+
+func (c *ScomClient) GetHealthStateForObjects(ctx context.Context, objects []models.MonitoringObject) ([]models.MonitoringDataResponse, error) {
+	return fanOut(ctx, c.pool, objects, func(ctx context.Context, object models.MonitoringObject) (models.MonitoringDataResponse, error) {
+		return requestToType[models.MonitoringDataResponse](ctx, c, "GET", "/OperationsManager/data/monitoring/"+object.ID, nil)
+	})
+}
+
+// GetStateChanges returns the health state changes of the monitors of the objects since from, each
+// carrying the object it belongs to.
+func (c *ScomClient) GetStateChanges(ctx context.Context, objects []models.MonitoringObject, from time.Time) ([]models.StateChangeEvent, error) {
+	responses, err := fanOut(ctx, c.pool, objects, func(ctx context.Context, object models.MonitoringObject) ([]models.StateChangeEvent, error) {
+		body := map[string]interface{}{
+			"objectId":  object.ID,
+			"startTime": from.UTC().Format(time.RFC3339),
+		}
+
+		changes, err := extensionRequest[models.StateChangeResponse](ctx, c, "POST", "/OperationsManager/data/stateChanges", body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get state changes of %s: %w", object.ID, err)
+		}
+
+		for i := range changes.Rows {
+			changes.Rows[i].Object = object
+		}
+
+		return changes.Rows, nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	var states []models.MonitoringDataResponse
+	var events []models.StateChangeEvent
+	for _, response := range responses {
+		events = append(events, response...)
+	}
+
+	return events, nil
+}
 
-	for _, object := range objects {
-		state, err := requestToType[models.MonitoringDataResponse](c, "GET", "/OperationsManager/data/monitoring/"+object.ID, nil)
+// GetMaintenanceHistory returns the maintenance mode windows of the objects, each carrying the
+// object it belongs to.
+func (c *ScomClient) GetMaintenanceHistory(ctx context.Context, objects []models.MonitoringObject) ([]models.MaintenanceWindow, error) {
+	histories, err := fanOut(ctx, c.pool, objects, func(ctx context.Context, object models.MonitoringObject) ([]models.MaintenanceWindow, error) {
+		history, err := extensionRequest[models.MaintenanceHistoryResponse](ctx, c, "GET", "/OperationsManager/data/maintenanceModeHistory/"+object.ID, nil)
 		if err != nil {
-			return states, err
+			return nil, fmt.Errorf("failed to get maintenance history of %s: %w", object.ID, err)
 		}
 
-		states = append(states, state)
+		for i := range history.Rows {
+			history.Rows[i].Object = object
+		}
+
+		return history.Rows, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return states, nil
+	var windows []models.MaintenanceWindow
+	for _, history := range histories {
+		windows = append(windows, history...)
+	}
+
+	return windows, nil
+}
+
+// StartMaintenanceMode puts the objects into maintenance mode until m.EndTime.
+func (c *ScomClient) StartMaintenanceMode(ctx context.Context, m models.MaintenanceMode) error {
+	_, err := extensionRequest[json.RawMessage](ctx, c, "POST", "/OperationsManager/data/maintenanceMode", m)
+	if err != nil {
+		return fmt.Errorf("failed to start maintenance mode: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateMaintenanceMode changes the end time, reason and comment of objects in maintenance mode.
+func (c *ScomClient) UpdateMaintenanceMode(ctx context.Context, m models.MaintenanceMode) error {
+	_, err := extensionRequest[json.RawMessage](ctx, c, "PUT", "/OperationsManager/data/maintenanceMode", m)
+	if err != nil {
+		return fmt.Errorf("failed to update maintenance mode: %w", err)
+	}
+
+	return nil
+}
+
+// StopMaintenanceMode ends maintenance mode for the objects.
+func (c *ScomClient) StopMaintenanceMode(ctx context.Context, m models.MaintenanceMode) error {
+	_, err := extensionRequest[json.RawMessage](ctx, c, "POST", "/OperationsManager/data/maintenanceMode/stop", m)
+	if err != nil {
+		return fmt.Errorf("failed to stop maintenance mode: %w", err)
+	}
+
+	return nil
 }
 
 // https://learn.microsoft.com/en-us/rest/api/operationsmanager/data/retrieve-monitoring-data?tabs=HTTP
-func (c *ScomClient) GetMonitoringData(ids []string) ([]models.MonitoringDataResponse, error) {
-	var (
-		result []models.MonitoringDataResponse
-		wg     = sync.WaitGroup{}
-	)
-
-	wg.Add(len(ids))
-
-	for _, _id := range ids {
-		go func(id string) {
-			healthStateData, err := requestToType[models.MonitoringDataResponse](c, "GET", "/OperationsManager/data/monitoring/"+id, nil)
-			if err == nil {
-				result = append(result, healthStateData)
-			} else {
-				//maybe log ?
-			}
+func (c *ScomClient) GetMonitoringData(ctx context.Context, ids []string) ([]models.MonitoringDataResponse, error) {
+	type monitoringResult struct {
+		data models.MonitoringDataResponse
+		ok   bool
+	}
 
-			wg.Done()
-		}(_id)
+	// Objects that fail to load are skipped rather than failing the whole request.
+	responses, err := fanOut(ctx, c.pool, ids, func(ctx context.Context, id string) (monitoringResult, error) {
+		healthStateData, err := requestToType[models.MonitoringDataResponse](ctx, c, "GET", "/OperationsManager/data/monitoring/"+id, nil)
+		if err != nil {
+			backend.Logger.Warn("Failed to get monitoring data", "objectID", id, "error", err)
+			return monitoringResult{}, nil
+		}
+
+		return monitoringResult{data: healthStateData, ok: true}, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	wg.Wait()
+	var result []models.MonitoringDataResponse
+	for _, response := range responses {
+		if response.ok {
+			result = append(result, response.data)
+		}
+	}
 
 	return result, nil
 }
 
-func (c *ScomClient) GetPerformanceData(duration int, instances []models.MonitoringObject, counters []models.PerformanceCounter) ([]models.PerformanceResponse, error) {
-	var performanceDataArray []models.PerformanceResponse
+// All counters are requested in a single body per instance; SCOM returns one dataset per counter
+// (and per counter instance) together with a legend row describing it.
+//
+// The endpoint only accepts a duration in minutes counted back from now, so the window requested
+// reaches back to from and samples outside [from, to] are trimmed from the response. A short range
+// far in the past therefore still downloads every sample up to now. Ranges are clamped to the
+// longest retention of the operational database, and ranges entirely older than that are rejected.
+func (c *ScomClient) GetPerformanceData(ctx context.Context, from, to time.Time, instances []models.MonitoringObject, counters []models.PerformanceCounter) ([]models.PerformanceResponse, error) {
+	oldest := time.Now().Add(-maxPerformanceRetention)
+	if to.Before(oldest) {
+		return nil, fmt.Errorf("time range ends more than %d days ago, before any performance data SCOM retains", int(maxPerformanceRetention.Hours()/24))
+	}
+	if from.Before(oldest) {
+		from = oldest
+	}
+
+	duration := int(math.Ceil(time.Since(from).Minutes()))
+	if duration < 1 {
+		duration = 1
+	}
+
+	performanceCounters := make([]interface{}, 0, len(counters))
+	for _, counter := range counters {
+		performanceCounters = append(performanceCounters, map[string]interface{}{
+			"countername":  counter.CounterName,
+			"objectname":   counter.ObjectName,
+			"instancename": counter.InstanceName,
+		})
+	}
 
-	for _, instance := range instances {
+	return fanOut(ctx, c.pool, instances, func(ctx context.Context, instance models.MonitoringObject) (models.PerformanceResponse, error) {
 		requestBody := models.ScomPerformanceRequest{
-			Duration: duration,
-			ID:       instance.ID,
-			// PerformanceCounters: counters,
-			PerformanceCounters: []interface{}{
-				map[string]interface{}{
-					"countername":  counters[0].CounterName,
-					"objectname":   counters[0].ObjectName,
-					"instancename": counters[0].InstanceName,
-				},
-			},
+			Duration:            duration,
+			ID:                  instance.ID,
+			PerformanceCounters: performanceCounters,
 		}
 
-		performanceData, err := requestToType[models.PerformanceResponse](c, "POST", "/OperationsManager/data/performance", requestBody)
+		performanceData, err := requestToType[models.PerformanceResponse](ctx, c, "POST", "/OperationsManager/data/performance", requestBody)
 		if err != nil {
-			return []models.PerformanceResponse{}, err
+			return models.PerformanceResponse{}, err
 		}
 
+		performanceData.Datasets = trimDatasets(performanceData.Datasets, from, to)
+
 		// Adding object information to the performance data.
 		performanceData.ObjectId = instance.ID
 		performanceData.ObjectDisplayName = instance.DisplayName
 		performanceData.ObjectPath = instance.Path
 		performanceData.ObjectFullName = instance.FullName
+		performanceData.ObjectScope = instance.Scope
 
-		performanceDataArray = append(performanceDataArray, performanceData)
-	}
-
-	return performanceDataArray, nil
+		return performanceData, nil
+	})
 }
 
-func (c *ScomClient) GetPerformanceCounters(objectIds []string) ([]models.PerformanceCounter, error) {
-	var wg sync.WaitGroup
-	uniqueCounters := sync.Map{}
-	errChan := make(chan error, len(objectIds))
+// trimDatasets drops samples outside [from, to] from every dataset.
+func trimDatasets(datasets []models.Dataset, from, to time.Time) []models.Dataset {
+	for i, dataset := range datasets {
+		trimmed := make(map[string]interface{}, len(dataset.Data))
+		for timeStr, value := range dataset.Data {
+			timeVal, err := time.Parse(time.RFC3339, timeStr)
+			if err != nil || timeVal.Before(from) || timeVal.After(to) {
+				continue
+			}
+			trimmed[timeStr] = value
+		}
+		datasets[i].Data = trimmed
+	}
 
-	for _, objectId := range objectIds {
-		wg.Add(1)
-		go func(id string) {
-			defer wg.Done()
+	return datasets
+}
 
-			response, err := requestToType[models.PerformanceCounterResponse](c, "GET", "/OperationsManager/data/performanceCounters/"+id, nil)
-			if err != nil {
-				errChan <- err
-				return
-			}
+func (c *ScomClient) GetPerformanceCounters(ctx context.Context, objectIds []string) ([]models.PerformanceCounter, error) {
+	responses, err := fanOut(ctx, c.pool, objectIds, func(ctx context.Context, id string) (models.PerformanceCounterResponse, error) {
+		return requestToType[models.PerformanceCounterResponse](ctx, c, "GET", "/OperationsManager/data/performanceCounters/"+id, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
 
-			for _, counter := range response.Rows {
-				uniqueCounters.Store(counter.CounterName, counter)
+	// Deduplicate by counter name, keeping the first occurrence in object order.
+	uniqueCounters := map[string]models.PerformanceCounter{}
+	for _, response := range responses {
+		for _, counter := range response.Rows {
+			if _, exists := uniqueCounters[counter.CounterName]; !exists {
+				uniqueCounters[counter.CounterName] = counter
 			}
-		}(objectId)
+		}
 	}
 
-	wg.Wait()
-	close(errChan)
-
-	// Collect errors if any
-	if len(errChan) > 0 {
-		return nil, <-errChan // Return the first error encountered
+	result := make([]models.PerformanceCounter, 0, len(uniqueCounters))
+	for _, counter := range uniqueCounters {
+		result = append(result, counter)
 	}
 
-	// Convert sync.Map to slice
-	result := []models.PerformanceCounter{}
-	uniqueCounters.Range(func(_, value interface{}) bool {
-		result = append(result, value.(models.PerformanceCounter))
-		return true
-	})
-
 	sort.Slice(result, func(i, j int) bool {
 		return result[i].CounterName < result[j].CounterName
 	})
@@ -307,10 +460,10 @@ func (c *ScomClient) GetPerformanceCounters(objectIds []string) ([]models.Perfor
 	return result, nil
 }
 
-func (c *ScomClient) GetClassesByDisplayName(query string) ([]models.MonitoringClass, error) {
+func (c *ScomClient) GetClassesByDisplayName(ctx context.Context, query string) ([]models.MonitoringClass, error) {
 
 	criteria := "DisplayName LIKE '%" + query + "%'"
-	classes, err := requestToType[models.ScomClassResponse](c, "POST", "/OperationsManager/data/scomClasses", criteria)
+	classes, err := requestToType[models.ScomClassResponse](ctx, c, "POST", "/OperationsManager/data/scomClasses", criteria)
 	if err != nil {
 		return []models.MonitoringClass{}, err
 	}
@@ -318,8 +471,8 @@ func (c *ScomClient) GetClassesByDisplayName(query string) ([]models.MonitoringC
 	return classes.ScopeDatas, nil
 }
 
-func (c *ScomClient) GetClassesForObject(id string) ([]models.MonitoringClass, error) {
-	classes, err := requestToType[models.ClassesForObjectResponse](c, "GET", "/OperationsManager/data/classesForObject/"+id, nil)
+func (c *ScomClient) GetClassesForObject(ctx context.Context, id string) ([]models.MonitoringClass, error) {
+	classes, err := requestToType[models.ClassesForObjectResponse](ctx, c, "GET", "/OperationsManager/data/classesForObject/"+id, nil)
 	if err != nil {
 		return []models.MonitoringClass{}, err
 	}
@@ -328,10 +481,10 @@ func (c *ScomClient) GetClassesForObject(id string) ([]models.MonitoringClass, e
 }
 
 // https://learn.microsoft.com/en-us/rest/api/operationsmanager/data/retrieve-group-data?tabs=HTTP
-func (c *ScomClient) GetGroups(query string) ([]models.ScomGroup, error) {
+func (c *ScomClient) GetGroups(ctx context.Context, query string) ([]models.ScomGroup, error) {
 	criteria := "DisplayName LIKE '%%'"
 
-	groups, err := requestToType[models.GroupResponse](c, "POST", "/OperationsManager/data/scomGroups", criteria)
+	groups, err := requestToType[models.GroupResponse](ctx, c, "POST", "/OperationsManager/data/scomGroups", criteria)
 	if err != nil {
 		return nil, err
 	}
@@ -340,23 +493,25 @@ func (c *ScomClient) GetGroups(query string) ([]models.ScomGroup, error) {
 }
 
 // Do we really have to query like this?
-func (c *ScomClient) GetObjects(objectIds []string) ([]models.MonitoringObject, error) {
-	var objects []models.MonitoringObject
-	for _, id := range objectIds {
+func (c *ScomClient) GetObjects(ctx context.Context, objectIds []string) ([]models.MonitoringObject, error) {
+	responses, err := fanOut(ctx, c.pool, objectIds, func(ctx context.Context, id string) (models.ScomObjectResponse, error) {
 		criteria := "Id = '" + id + "'"
-		object, err := requestToType[models.ScomObjectResponse](c, "POST", "/OperationsManager/data/scomObjects", criteria)
-		if err != nil {
-			return nil, err
-		}
+		return requestToType[models.ScomObjectResponse](ctx, c, "POST", "/OperationsManager/data/scomObjects", criteria)
+	})
+	if err != nil {
+		return nil, err
+	}
 
+	var objects []models.MonitoringObject
+	for _, object := range responses {
 		objects = append(objects, object.ScopeDatas...)
 	}
 
 	return objects, nil
 }
 
-func (c *ScomClient) GetObjectsByClass(className string) ([]models.MonitoringObject, error) {
-	objects, err := requestToType[models.ObjectByClassResponse](c, "POST", "/OperationsManager/data/scomObjectsByClass", className)
+func (c *ScomClient) GetObjectsByClass(ctx context.Context, className string) ([]models.MonitoringObject, error) {
+	objects, err := requestToType[models.ObjectByClassResponse](ctx, c, "POST", "/OperationsManager/data/scomObjectsByClass", className)
 	if err != nil {
 		return []models.MonitoringObject{}, err
 	}
@@ -365,17 +520,17 @@ func (c *ScomClient) GetObjectsByClass(className string) ([]models.MonitoringObj
 }
 
 // https://learn.microsoft.com/en-us/rest/api/operationsmanager/data/retrieve-state-data?tabs=HTTP
-func (c *ScomClient) GetStateData(groupId, classId string) (models.StateDataResponse, error) {
+func (c *ScomClient) GetStateData(ctx context.Context, groupId, classId string) (models.StateDataResponse, error) {
 
 	body := models.StateDataRequestBody{
 		ClassID:        classId,
 		GroupID:        groupId,
 		ObjectIds:      map[string]interface{}{},
 		Criteria:       "",
-		DisplayColumns: []string{"healthstate", "displayname", "path", "maintenancemode"},
+		DisplayColumns: []string{"healthstate", "displayname", "path", "maintenancemode", "isavailable"},
 	}
 
-	group, err := requestToType[models.StateDataResponse](c, "POST", "/OperationsManager/data/state", body)
+	group, err := requestToType[models.StateDataResponse](ctx, c, "POST", "/OperationsManager/data/state", body)
 	if err != nil {
 		return models.StateDataResponse{}, err
 	}
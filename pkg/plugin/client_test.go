@@ -0,0 +1,279 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+func stubAuthenticate(context.Context, *models.PluginSettings) (AuthTokens, error) {
+	return AuthTokens{SessionID: "SCOMSessionId=session", CSRFToken: "csrf", AuthToken: "basic"}, nil
+}
+
+// newTestClient returns a ScomClient talking to handler with stubbed authentication.
+func newTestClient(t *testing.T, handler http.Handler) *ScomClient {
+	t.Helper()
+
+	return newTestClientWithAuth(t, handler, stubAuthenticate)
+}
+
+// newTestClientWithAuth returns a ScomClient talking to handler that authenticates with authenticate.
+func newTestClientWithAuth(t *testing.T, handler http.Handler, authenticate func(context.Context, *models.PluginSettings) (AuthTokens, error)) *ScomClient {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	settings := &models.PluginSettings{Url: server.URL, AuthMode: models.AuthModeBasic}
+	tokens, err := newTokenManager(context.Background(), settings, authenticate, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &ScomClient{settings: settings, tokens: tokens, pool: NewWorkerPool(4)}
+
+	httpClient, err := httpclient.New(httpclient.Options{
+		Middlewares: []httpclient.Middleware{client.AuthMiddleware()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.httpClient = httpClient
+
+	t.Cleanup(client.Close)
+
+	return client
+}
+
+func TestRequestCancelledByContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetAlerts(ctx, "Severity = 2", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("request was not cancelled, took %s", elapsed)
+	}
+}
+
+func TestExtensionRequestNeedsSetting(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		json.NewEncoder(w).Encode(models.MaintenanceHistoryResponse{})
+	}))
+	objects := []models.MonitoringObject{{ID: "1"}}
+
+	if _, err := client.GetMaintenanceHistory(context.Background(), objects); !errors.Is(err, errServerExtensionDisabled) {
+		t.Fatalf("expected the extension to be disabled, got %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("expected no request without the extension, got %d", calls.Load())
+	}
+
+	client.settings.ServerExtension = true
+	if _, err := client.GetMaintenanceHistory(context.Background(), objects); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected one request with the extension, got %d", calls.Load())
+	}
+}
+
+func TestGetPerformanceDataHonoursTimeRange(t *testing.T) {
+	from := time.Now().Add(-3 * time.Hour).Truncate(time.Minute)
+	to := from.Add(time.Hour)
+
+	var request models.ScomPerformanceRequest
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Error(err)
+		}
+
+		json.NewEncoder(w).Encode(models.PerformanceResponse{Datasets: []models.Dataset{{
+			ID: "a",
+			Data: map[string]interface{}{
+				from.Add(-time.Minute).Format(time.RFC3339):     1.0,
+				from.Format(time.RFC3339):                       2.0,
+				to.Format(time.RFC3339):                         3.0,
+				to.Add(time.Minute).Format(time.RFC3339):        4.0,
+				time.Now().Add(-time.Hour).Format(time.RFC3339): 5.0,
+			},
+		}}})
+	}))
+
+	result, err := client.GetPerformanceData(context.Background(), from, to, []models.MonitoringObject{{ID: "1"}}, []models.PerformanceCounter{{CounterName: "% Processor Time"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if request.Duration < 180 {
+		t.Errorf("requested duration %d does not reach back to the start of the range", request.Duration)
+	}
+	if got := len(result[0].Datasets[0].Data); got != 2 {
+		t.Errorf("expected 2 samples inside the range, got %d", got)
+	}
+}
+
+func TestGetPerformanceDataClampsToRetention(t *testing.T) {
+	var request models.ScomPerformanceRequest
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Error(err)
+		}
+
+		json.NewEncoder(w).Encode(models.PerformanceResponse{})
+	}))
+
+	counters := []models.PerformanceCounter{{CounterName: "% Processor Time"}}
+	instances := []models.MonitoringObject{{ID: "1"}}
+
+	from := time.Now().Add(-2 * maxPerformanceRetention)
+	if _, err := client.GetPerformanceData(context.Background(), from, from.Add(time.Hour), instances, counters); err == nil {
+		t.Fatal("expected an error for a range older than the retention")
+	}
+
+	if _, err := client.GetPerformanceData(context.Background(), from, time.Now(), instances, counters); err != nil {
+		t.Fatal(err)
+	}
+	if limit := int(maxPerformanceRetention.Minutes()) + 1; request.Duration > limit {
+		t.Errorf("requested duration %d exceeds the retention of %d minutes", request.Duration, limit)
+	}
+}
+
+// sessionServer answers with status to requests made with a session other than the current one,
+// which authenticating replaces, and with 200 otherwise.
+type sessionServer struct {
+	status   int
+	logins   atomic.Int32
+	requests atomic.Int32
+}
+
+func (s *sessionServer) authenticate(context.Context, *models.PluginSettings) (AuthTokens, error) {
+	n := s.logins.Add(1)
+	return AuthTokens{SessionID: fmt.Sprintf("SCOMSessionId=%d", n), CSRFToken: "csrf"}, nil
+}
+
+func (s *sessionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	if r.Header.Get("Cookie") != fmt.Sprintf("SCOMSessionId=%d", s.logins.Load()) {
+		w.WriteHeader(s.status)
+	}
+}
+
+// expireSession makes the current session of client stale on the server, aged past the minimum
+// age 401 responses are taken as expiry at.
+func (s *sessionServer) expireSession(client *ScomClient) {
+	s.logins.Add(1)
+	client.tokens.mu.Lock()
+	client.tokens.issuedAt = time.Now().Add(-time.Hour)
+	client.tokens.mu.Unlock()
+}
+
+func TestAuthMiddlewareRefreshesExpiredSession(t *testing.T) {
+	for _, status := range []int{440, http.StatusUnauthorized} {
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			server := &sessionServer{status: status}
+			client := newTestClientWithAuth(t, server, server.authenticate)
+			server.expireSession(client)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					resp, err := client.request(context.Background(), "GET", "/OperationsManager/data/alert", nil)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					resp.Body.Close()
+					if resp.StatusCode != http.StatusOK {
+						t.Errorf("expected the retry to succeed, got %d", resp.StatusCode)
+					}
+				}()
+			}
+			wg.Wait()
+
+			// One login at startup, one expiring the session, and one shared refresh.
+			if n := server.logins.Load(); n != 3 {
+				t.Errorf("expected a single shared refresh, got %d logins", n)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareKeepsSessionOnDenial(t *testing.T) {
+	for _, status := range []int{http.StatusForbidden, http.StatusUnauthorized} {
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			server := &sessionServer{status: status}
+			client := newTestClientWithAuth(t, server, server.authenticate)
+
+			// The session is fresh, so neither response means it expired.
+			server.logins.Add(1)
+			for i := 0; i < 5; i++ {
+				resp, err := client.request(context.Background(), "GET", "/OperationsManager/data/alert", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != status {
+					t.Errorf("expected %d to be returned, got %d", status, resp.StatusCode)
+				}
+			}
+
+			if n := server.logins.Load(); n != 2 {
+				t.Errorf("expected no refresh, got %d logins", n)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareRetriesOnce(t *testing.T) {
+	server := &sessionServer{status: 440}
+	client := newTestClientWithAuth(t, server, func(ctx context.Context, settings *models.PluginSettings) (AuthTokens, error) {
+		// Every session is rejected, including the refreshed one.
+		server.logins.Add(1)
+		return AuthTokens{SessionID: "SCOMSessionId=rejected", CSRFToken: "csrf"}, nil
+	})
+
+	resp, err := client.request(context.Background(), "GET", "/OperationsManager/data/alert", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 440 {
+		t.Errorf("expected the rejected retry to be returned, got %d", resp.StatusCode)
+	}
+	if n := server.requests.Load(); n != 2 {
+		t.Errorf("expected the request and a single retry, got %d requests", n)
+	}
+	if n := server.logins.Load(); n != 2 {
+		t.Errorf("expected a single refresh, got %d logins", n)
+	}
+}
@@ -0,0 +1,201 @@
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+// defaultAlertCriteria returns new critical alerts when a query specifies neither criteria nor filter.
+const defaultAlertCriteria = "Severity = 2 and ResolutionState = 0"
+
+// criteriaTimeLayout is the layout of UTC timestamps in SCOM criteria.
+const criteriaTimeLayout = "2006-01-02T15:04:05"
+
+var guidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+var alertSeverities = map[string]int{
+	"information": 0,
+	"warning":     1,
+	"error":       2,
+	"critical":    2,
+}
+
+var alertPriorities = map[string]int{
+	"low":    0,
+	"normal": 1,
+	"high":   2,
+}
+
+// alertCriteria returns the SCOM criteria of an alert query. Raw criteria take precedence over the
+// structured filter, which is validated and compiled.
+func alertCriteria(q models.AlertQuery, now time.Time) (string, error) {
+	if trimmedCriteria := strings.TrimSpace(q.Criteria); trimmedCriteria != "" {
+		return trimmedCriteria, nil
+	}
+
+	if q.Filter == nil {
+		return defaultAlertCriteria, nil
+	}
+
+	criteria, err := compileAlertFilter(*q.Filter, now)
+	if err != nil {
+		return "", fmt.Errorf("invalid alert filter: %w", err)
+	}
+
+	if criteria == "" {
+		return defaultAlertCriteria, nil
+	}
+
+	return criteria, nil
+}
+
+// withAlertTimeRange restricts criteria to the alerts raised or modified within timeRange, depending
+// on filter, so SCOM only returns those. Timestamps in criteria have second precision, the end of the
+// range is rounded up.
+func withAlertTimeRange(criteria, filter string, timeRange backend.TimeRange) string {
+	property := "TimeRaised"
+	switch filter {
+	case "":
+		return criteria
+	case models.AlertTimeFilterModified:
+		property = "LastModified"
+	}
+
+	from := timeRange.From.UTC().Format(criteriaTimeLayout)
+	to := timeRange.To.UTC().Truncate(time.Second).Add(time.Second).Format(criteriaTimeLayout)
+
+	return fmt.Sprintf("(%s) AND %s >= %s AND %s < %s", criteria, property, quoteCriteria(from), property, quoteCriteria(to))
+}
+
+// compileAlertFilter compiles a structured filter into SCOM criteria. Text values are quoted and
+// escaped, enumerations and ids are validated.
+func compileAlertFilter(filter models.AlertFilter, now time.Time) (string, error) {
+	var conditions []string
+
+	if len(filter.Severities) > 0 {
+		values, err := enumValues(filter.Severities, alertSeverities, "severity")
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, anyOf("Severity", values))
+	}
+
+	if len(filter.Priorities) > 0 {
+		values, err := enumValues(filter.Priorities, alertPriorities, "priority")
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, anyOf("Priority", values))
+	}
+
+	if len(filter.ResolutionStates) > 0 {
+		var values []string
+		for _, state := range filter.ResolutionStates {
+			if state < 0 || state > 255 {
+				return "", fmt.Errorf("resolution state must be between 0 and 255: %d", state)
+			}
+			values = append(values, strconv.Itoa(state))
+		}
+		conditions = append(conditions, anyOf("ResolutionState", values))
+	}
+
+	if len(filter.ObjectIds) > 0 {
+		values, err := guidValues(filter.ObjectIds, "object id")
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, anyOf("MonitoringObjectId", values))
+	}
+
+	if len(filter.ClassIds) > 0 {
+		values, err := guidValues(filter.ClassIds, "class id")
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, anyOf("MonitoringClassId", values))
+	}
+
+	if filter.NameContains != "" {
+		conditions = append(conditions, "Name LIKE "+quoteCriteria("%"+escapeLike(filter.NameContains)+"%"))
+	}
+
+	if filter.Owner != "" {
+		conditions = append(conditions, "Owner = "+quoteCriteria(filter.Owner))
+	}
+
+	if filter.MaxAgeMinutes < 0 {
+		return "", fmt.Errorf("age must not be negative: %d", filter.MaxAgeMinutes)
+	}
+	if filter.MaxAgeMinutes > 0 {
+		raisedAfter := now.Add(-time.Duration(filter.MaxAgeMinutes) * time.Minute).UTC()
+		conditions = append(conditions, "TimeRaised >= "+quoteCriteria(raisedAfter.Format(criteriaTimeLayout)))
+	}
+
+	return strings.Join(conditions, " AND "), nil
+}
+
+// enumValues maps names or numbers to the numeric values SCOM criteria expect.
+func enumValues(values []string, names map[string]int, kind string) ([]string, error) {
+	var result []string
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+
+		if n, ok := names[value]; ok {
+			result = append(result, strconv.Itoa(n))
+			continue
+		}
+
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= 2 {
+			result = append(result, strconv.Itoa(n))
+			continue
+		}
+
+		return nil, fmt.Errorf("unknown %s: %q", kind, value)
+	}
+
+	return result, nil
+}
+
+// guidValues validates ids and returns them quoted.
+func guidValues(ids []string, kind string) ([]string, error) {
+	var result []string
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if !guidPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid %s: %q", kind, id)
+		}
+		result = append(result, quoteCriteria(id))
+	}
+
+	return result, nil
+}
+
+// anyOf matches property against any of the already formatted values.
+func anyOf(property string, values []string) string {
+	if len(values) == 1 {
+		return property + " = " + values[0]
+	}
+
+	conditions := make([]string, len(values))
+	for i, value := range values {
+		conditions[i] = property + " = " + value
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// quoteCriteria quotes a text value for SCOM criteria, doubling embedded single quotes.
+func quoteCriteria(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// escapeLike escapes the LIKE wildcards in value so it is matched literally.
+func escapeLike(value string) string {
+	return strings.NewReplacer("[", "[[]", "%", "[%]", "_", "[_]").Replace(value)
+}
@@ -0,0 +1,85 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+func TestAlertCriteria(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		query models.AlertQuery
+		want  string
+	}{
+		{"default", models.AlertQuery{}, defaultAlertCriteria},
+		{"raw overrides filter", models.AlertQuery{Criteria: "  Severity = 1 ", Filter: &models.AlertFilter{Owner: "x"}}, "Severity = 1"},
+		{
+			"structured",
+			models.AlertQuery{Filter: &models.AlertFilter{
+				Severities:       []string{"Error", "1"},
+				ResolutionStates: []int{0},
+				ClassIds:         []string{"ea99500d-8d52-fc52-b5a5-10dcd1e9d2bd"},
+				NameContains:     "50%_[disk]",
+				Owner:            "O'Brien",
+				MaxAgeMinutes:    60,
+			}},
+			"(Severity = 2 OR Severity = 1) AND ResolutionState = 0 AND MonitoringClassId = 'ea99500d-8d52-fc52-b5a5-10dcd1e9d2bd'" +
+				" AND Name LIKE '%50[%][_][[]disk]%' AND Owner = 'O''Brien' AND TimeRaised >= '2024-01-15T11:00:00'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := alertCriteria(tt.query, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithAlertTimeRange(t *testing.T) {
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 1, 11, 0, 0, 0, 500000000, time.UTC),
+	}
+
+	tests := []struct {
+		filter string
+		want   string
+	}{
+		{"", "Severity = 2 OR Severity = 1"},
+		{models.AlertTimeFilterRaised, "(Severity = 2 OR Severity = 1) AND TimeRaised >= '2024-01-10T00:00:00' AND TimeRaised < '2024-01-11T00:00:01'"},
+		{models.AlertTimeFilterModified, "(Severity = 2 OR Severity = 1) AND LastModified >= '2024-01-10T00:00:00' AND LastModified < '2024-01-11T00:00:01'"},
+	}
+
+	for _, tt := range tests {
+		if got := withAlertTimeRange("Severity = 2 OR Severity = 1", tt.filter, timeRange); got != tt.want {
+			t.Errorf("%q: got  %s\nwant %s", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestAlertCriteriaValidation(t *testing.T) {
+	filters := []models.AlertFilter{
+		{Severities: []string{"fatal"}},
+		{Priorities: []string{"7"}},
+		{ResolutionStates: []int{256}},
+		{ObjectIds: []string{"' OR 1=1 --"}},
+		{MaxAgeMinutes: -1},
+	}
+
+	for _, filter := range filters {
+		if _, err := alertCriteria(models.AlertQuery{Filter: &filter}, time.Now()); err == nil {
+			t.Errorf("expected validation error for %+v", filter)
+		}
+	}
+}
@@ -3,13 +3,13 @@ package plugin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
@@ -39,7 +39,7 @@ func NewDatasource(ctx context.Context, settings backend.DataSourceInstanceSetti
 		return nil, fmt.Errorf("plugin settings: %w", err)
 	}
 
-	client, err := NewScomClient(httpClientOptions, pluginSettings)
+	client, err := NewScomClient(ctx, httpClientOptions, pluginSettings)
 	if err != nil {
 		return nil, fmt.Errorf("scom client initialization: %w", err)
 	}
@@ -53,10 +53,15 @@ func NewDatasource(ctx context.Context, settings backend.DataSourceInstanceSetti
 type ScomDatasource struct {
 	settings backend.DataSourceInstanceSettings
 	client   *ScomClient
+	// Resolution state names of the management group, loaded on first use.
+	resolutionStates resolutionStateCache
 }
 
 func (d *ScomDatasource) Dispose() {
 	// Clean up datasource instance resources.
+	if d.client != nil {
+		d.client.Close()
+	}
 }
 
 func (d *ScomDatasource) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
@@ -71,7 +76,7 @@ func (d *ScomDatasource) QueryData(ctx context.Context, req *backend.QueryDataRe
 
 	for _, q := range req.Queries {
 		go func(query backend.DataQuery) {
-			frames, err := d.handleQuery(query)
+			frames, err := d.handleQuery(ctx, query)
 
 			// //TODO: Get correct errorsource
 			response.Set(query.RefID, backend.DataResponse{
@@ -115,7 +120,7 @@ func (d *ScomDatasource) QueryData(ctx context.Context, req *backend.QueryDataRe
 //  AlertsCriteria string `json:"alertsCriteria"`
 // }
 
-func (d *ScomDatasource) handleQuery(query backend.DataQuery) (data.Frames, error) {
+func (d *ScomDatasource) handleQuery(ctx context.Context, query backend.DataQuery) (data.Frames, error) {
 	// var qm models.QueryModel
 	// if err := json.Unmarshal(query.JSON, &qm); err != nil {
 	//  return nil, err
@@ -133,79 +138,217 @@ func (d *ScomDatasource) handleQuery(query backend.DataQuery) (data.Frames, erro
 	switch q := scomQuery.(type) {
 	case models.AlertQuery:
 		{
-			criteria := "Severity = 2 and ResolutionState = 0"
-			if q.Criteria != "" {
-				trimmedCriteria := strings.TrimSpace(q.Criteria)
-				if len(trimmedCriteria) > 0 {
-					criteria = trimmedCriteria
-				}
-			}
-			alerts, err := d.client.GetAlerts(criteria)
+			alerts, err := d.queryAlerts(ctx, q, query.TimeRange, q.Columns)
+			if err != nil {
+				return nil, err
+			}
+
+			names := d.resolutionStateNames(ctx)
+
+			if len(q.Columns) > 0 {
+				return d.buildAlertColumnsFrame(alerts, normalizeAlertColumns(q.Columns), names), nil
+			}
+
+			return d.buildAlertsFrame(alerts, names), nil
+		}
+	case models.AlertAggregationQuery:
+		{
+			if err := validateAlertGroupBy(q.GroupBy); err != nil {
+				return nil, err
+			}
+
+			alerts, err := d.queryAlerts(ctx, q.AlertQuery, query.TimeRange, alertClassNameColumns)
+			if err != nil {
+				return nil, err
+			}
+
+			return d.buildAlertAggregationFrame(alerts, q.GroupBy, d.resolutionStateNames(ctx)), nil
+		}
+	case models.AlertAnnotationQuery:
+		{
+			alerts, err := d.queryAlerts(ctx, q.AlertQuery, query.TimeRange, alertClassNameColumns)
 			if err != nil {
 				return nil, err
 			}
-			return d.buildAlertsFrame(alerts), nil
+
+			if q.TimeFilter == "" {
+				alerts = filterAlertsOverlapping(alerts, query.TimeRange)
+			}
+
+			return d.buildAlertAnnotationsFrame(alerts), nil
 		}
 	case models.PerformanceQuery:
 		{
-			if q.Counters == nil {
+			if len(q.Counters) == 0 {
 				return nil, fmt.Errorf("counters are required")
 			}
 
-			duration := int(query.TimeRange.Duration().Minutes())
+			if !isValidAggregation(q.Aggregation) {
+				return nil, fmt.Errorf("unknown aggregation: %s", q.Aggregation)
+			}
 
-			//Are we getting performance by group? Get all instances belonging to this group and class
-			if len(q.Groups) > 0 {
-				groupInstances, err := d.client.GetStateData(q.Groups[0].ID, q.Classes[0].ID)
-				if err != nil {
-					return nil, err
-				}
+			switch q.Format {
+			case "", models.PerformanceFormatTable, models.PerformanceFormatTimeSeries, models.PerformanceFormatWide:
+			default:
+				return nil, fmt.Errorf("unknown format: %s", q.Format)
+			}
 
-				q.Instances = groupInstances.Rows
+			switch q.Mode {
+			case "", models.PerformanceModeSeries, models.PerformanceModeSummary:
+			default:
+				return nil, fmt.Errorf("unknown mode: %s", q.Mode)
 			}
 
-			//No groups or instances defined, use wildcard for instances
-			if len(q.Instances) == 0 || q.Instances[0].ID == "*" {
-				allClassInstances, err := d.client.GetObjectsByClass(q.Classes[0].ID)
-				if err != nil {
-					return nil, err
-				}
+			if err := validateRanking(q.Ranking); err != nil {
+				return nil, err
+			}
 
-				q.Instances = allClassInstances
+			if err := validateRollup(q.Rollup, q.Groups); err != nil {
+				return nil, err
 			}
 
-			performanceData, err := d.client.GetPerformanceData(duration, q.Instances, q.Counters)
+			//Union of all instances belonging to the selected groups and classes, or all class instances for a wildcard
+			instances, err := d.selectInstances(ctx, q.ObjectSelection)
 			if err != nil {
 				return nil, err
 			}
-			return d.buildPerformanceFrame(performanceData, q.Counters[0].CounterName), nil
+			q.Instances = instances
+
+			performanceData, err := d.client.GetPerformanceData(ctx, query.TimeRange.From, query.TimeRange.To, q.Instances, q.Counters)
+			if err != nil {
+				return nil, err
+			}
+
+			series := toPerformanceSeries(performanceData, q.Counters)
+
+			// Rolled up series are already resampled to the interval.
+			interval := resampleInterval(query)
+			if q.Rollup != nil {
+				series = rollupSeries(series, q.Rollup, interval, q.Aggregation)
+			}
+
+			// Rank on full resolution samples, before resampling.
+			series = rankSeries(series, q.Ranking, rangeEndsNow(query.TimeRange))
+
+			if q.Mode == models.PerformanceModeSummary {
+				return d.buildPerformanceSummaryFrame(series, rangeEndsNow(query.TimeRange)), nil
+			}
+
+			if q.Rollup == nil {
+				series = resampleSeries(series, interval, q.Aggregation, query.MaxDataPoints)
+			}
+
+			switch q.Format {
+			case models.PerformanceFormatTimeSeries:
+				return d.buildPerformanceTimeSeriesFrames(series), nil
+			case models.PerformanceFormatWide:
+				return d.buildPerformanceWideFrame(series), nil
+			}
+
+			return d.buildPerformanceFrame(series), nil
 		}
-	case models.StateQuery:
+	case models.MaintenanceQuery:
 		{
-			if len(q.Classes) == 0 {
-				return nil, fmt.Errorf("required property 'classes' is missing or empty")
+			instances, err := d.selectInstances(ctx, q.ObjectSelection)
+			if err != nil {
+				return nil, err
 			}
 
-			if len(q.Groups) > 0 {
-				states, err := d.client.GetStateData(q.Groups[0].ID, q.Classes[0].ID)
-				if err != nil {
-					return nil, err
-				}
+			windows, err := d.client.GetMaintenanceHistory(ctx, instances)
+			if err != nil {
+				return nil, err
+			}
 
-				return d.buildHealthStateGroupFrame(states), nil
+			return d.buildMaintenanceFrame(windows, query.TimeRange), nil
+		}
+	case models.HealthExplorerQuery:
+		{
+			instances, err := d.selectInstances(ctx, q.ObjectSelection)
+			if err != nil {
+				return nil, err
+			}
+
+			states, err := d.client.GetHealthStateForObjects(ctx, instances)
+			if err != nil {
+				return nil, err
+			}
+
+			return d.buildHealthExplorerFrames(states, instances), nil
+		}
+	case models.StateHistoryQuery:
+		{
+			if err := validateStateHistoryLevel(q.Level); err != nil {
+				return nil, err
+			}
+
+			instances, err := d.selectInstances(ctx, q.ObjectSelection)
+			if err != nil {
+				return nil, err
+			}
+
+			// The current states anchor the history, which is walked back from now.
+			states, err := d.client.GetHealthStateForObjects(ctx, instances)
+			if err != nil {
+				return nil, err
+			}
+
+			events, err := d.client.GetStateChanges(ctx, instances, query.TimeRange.From)
+			if err != nil {
+				return nil, err
+			}
+
+			series := stateHistory(states, instances, events, q.Level, query.TimeRange, time.Now())
+
+			return d.buildStateHistoryFrames(series), nil
+		}
+	case models.AvailabilityQuery:
+		{
+			// State changes and maintenance history need the server extension, fail before requesting health states.
+			if !d.client.settings.ServerExtension {
+				return nil, errServerExtensionDisabled
 			}
 
-			//No groups, use wildcard for instances
-			if len(q.Instances) == 0 || q.Instances[0].ID == "*" {
-				allClassInstances, err := d.client.GetObjectsByClass(q.Classes[0].ID)
-				if err != nil {
-					return nil, err
-				}
+			instances, err := d.selectInstances(ctx, q.ObjectSelection)
+			if err != nil {
+				return nil, err
+			}
 
-				q.Instances = allClassInstances
+			states, err := d.client.GetHealthStateForObjects(ctx, instances)
+			if err != nil {
+				return nil, err
+			}
+
+			events, err := d.client.GetStateChanges(ctx, instances, query.TimeRange.From)
+			if err != nil {
+				return nil, err
 			}
 
-			states, err := d.client.GetHealthStateForObjects(q.Instances)
+			windows, err := d.client.GetMaintenanceHistory(ctx, instances)
+			if err != nil {
+				return nil, err
+			}
+
+			now := time.Now()
+			series := stateHistory(states, instances, events, models.StateHistoryLevelObject, query.TimeRange, now)
+
+			return d.buildAvailabilityFrames(series, windows, now, q.ExcludeMaintenance), nil
+		}
+	case models.StateQuery:
+		{
+			//Union of all instances belonging to the selected groups and classes, or all class instances for a wildcard
+			instances, err := d.selectInstances(ctx, q.ObjectSelection)
+			if err != nil {
+				return nil, err
+			}
+
+			// Group state data already carries the health state of every member.
+			if len(q.Groups) > 0 {
+				return d.buildHealthStateGroupFrame(models.StateDataResponse{Rows: instances}), nil
+			}
+
+			q.Instances = instances
+
+			states, err := d.client.GetHealthStateForObjects(ctx, q.Instances)
 			if err != nil {
 				return nil, err
 			}
@@ -217,55 +360,50 @@ func (d *ScomDatasource) handleQuery(query backend.DataQuery) (data.Frames, erro
 	return nil, fmt.Errorf("unexpected value of Type")
 }
 
-func (d *ScomDatasource) buildPerformanceFrame(performanceData []models.PerformanceResponse, counterName string) data.Frames {
+func (d *ScomDatasource) buildPerformanceFrame(series []performanceSeries) data.Frames {
 	frames := data.Frames{}
 
-	for _, entry := range performanceData {
-		frameName := entry.ObjectDisplayName
-		frame := data.NewFrame(frameName)
+	// One frame per object and counter, so every series gets its own value field.
+	for _, entry := range series {
+		frame := data.NewFrame(entry.Name)
+
+		rowCount := len(entry.Samples)
 
 		// Define fields for the frame
-		timeField := data.NewField("Time", nil, []time.Time{})
-		valueField := data.NewField("Value", nil, []float64{})
-		objectIdField := data.NewField("Object id", nil, []string{})
-		objectDisplayNameField := data.NewField("Object display name", nil, []string{})
-		objectPathField := data.NewField("Object paths", nil, []string{})
-		objectFullNameField := data.NewField("Object full name", nil, []string{})
-		counterNameField := data.NewField("Counter name", nil, []string{})
-
-		for _, dataset := range entry.Datasets {
-			// Sort timestamps to ensure order
-			keys := make([]string, 0, len(dataset.Data))
-			for key := range dataset.Data {
-				keys = append(keys, key)
-			}
-			sort.Strings(keys)
-
-			for _, timeStr := range keys {
-				timeVal, err := time.Parse(time.RFC3339, timeStr)
-				if err != nil {
-					backend.Logger.Error("Error parsing time", "error", err)
-					continue
-				}
-
-				value, ok := dataset.Data[timeStr].(float64)
-				if !ok {
-					backend.Logger.Error("Invalid value type", "time", timeStr, "entry", entry.ObjectDisplayName)
-					continue
-				}
-
-				timeField.Append(timeVal)
-				valueField.Append(value)
-				objectIdField.Append(entry.ObjectId)
-				objectDisplayNameField.Append(entry.ObjectDisplayName)
-				objectPathField.Append(entry.ObjectPath)
-				objectFullNameField.Append(entry.ObjectFullName)
-				counterNameField.Append(counterName)
-			}
+		times := make([]time.Time, rowCount)
+		values := make([]float64, rowCount)
+		objectIds := make([]string, rowCount)
+		objectDisplayNames := make([]string, rowCount)
+		objectPaths := make([]string, rowCount)
+		objectFullNames := make([]string, rowCount)
+		counterNames := make([]string, rowCount)
+		groups := make([]string, rowCount)
+		classes := make([]string, rowCount)
+
+		for i, sample := range entry.Samples {
+			times[i] = sample.Time
+			values[i] = sample.Value
+			objectIds[i] = entry.ObjectId
+			objectDisplayNames[i] = entry.ObjectDisplayName
+			objectPaths[i] = entry.ObjectPath
+			objectFullNames[i] = entry.ObjectFullName
+			counterNames[i] = entry.Counter.CounterName
+			groups[i] = scopeGroups(entry.ObjectScope)
+			classes[i] = scopeClasses(entry.ObjectScope)
 		}
 
 		// Add fields to the frame
-		frame.Fields = append(frame.Fields, timeField, valueField, objectIdField, objectDisplayNameField, objectPathField, objectFullNameField, counterNameField)
+		frame.Fields = append(frame.Fields,
+			data.NewField("Time", nil, times),
+			data.NewField("Value", nil, values),
+			data.NewField("Object id", nil, objectIds),
+			data.NewField("Object display name", nil, objectDisplayNames),
+			data.NewField("Object paths", nil, objectPaths),
+			data.NewField("Object full name", nil, objectFullNames),
+			data.NewField("Counter name", nil, counterNames),
+			data.NewField("Group", nil, groups),
+			data.NewField("Class", nil, classes),
+		)
 		frame.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeGraph})
 
 		// Add the frame to the collection
@@ -275,7 +413,145 @@ func (d *ScomDatasource) buildPerformanceFrame(performanceData []models.Performa
 	return frames
 }
 
-func (d *ScomDatasource) buildAlertsFrame(alerts models.ScomAlert) data.Frames {
+// buildPerformanceTimeSeriesFrames returns a frame per series holding only time and value, with the
+// series identity carried by labels so legends and overrides work on them.
+func (d *ScomDatasource) buildPerformanceTimeSeriesFrames(series []performanceSeries) data.Frames {
+	frames := make(data.Frames, 0, len(series))
+
+	for _, entry := range series {
+		times := make([]time.Time, len(entry.Samples))
+		values := make([]float64, len(entry.Samples))
+		for i, sample := range entry.Samples {
+			times[i] = sample.Time
+			values[i] = sample.Value
+		}
+
+		valueField := data.NewField("Value", performanceLabels(entry), values)
+		valueField.SetConfig(&data.FieldConfig{DisplayNameFromDS: entry.Name})
+
+		frame := data.NewFrame(entry.Name,
+			data.NewField("Time", nil, times),
+			valueField,
+		)
+		frame.SetMeta(&data.FrameMeta{Type: data.FrameTypeTimeSeriesMulti, PreferredVisualization: data.VisTypeGraph})
+
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+// buildPerformanceWideFrame returns a single frame with the union of all timestamps and a labelled
+// value field per series. Series without a sample at a timestamp hold null there.
+func (d *ScomDatasource) buildPerformanceWideFrame(series []performanceSeries) data.Frames {
+	timeSet := map[time.Time]struct{}{}
+	for _, entry := range series {
+		for _, sample := range entry.Samples {
+			timeSet[sample.Time] = struct{}{}
+		}
+	}
+
+	times := make([]time.Time, 0, len(timeSet))
+	for t := range timeSet {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+
+	index := make(map[time.Time]int, len(times))
+	for i, t := range times {
+		index[t] = i
+	}
+
+	frame := data.NewFrame("performance", data.NewField("Time", nil, times))
+
+	for _, entry := range series {
+		values := make([]*float64, len(times))
+		for _, sample := range entry.Samples {
+			value := sample.Value
+			values[index[sample.Time]] = &value
+		}
+
+		valueField := data.NewField("Value", performanceLabels(entry), values)
+		valueField.SetConfig(&data.FieldConfig{DisplayNameFromDS: entry.Name})
+
+		frame.Fields = append(frame.Fields, valueField)
+	}
+
+	frame.SetMeta(&data.FrameMeta{Type: data.FrameTypeTimeSeriesWide, PreferredVisualization: data.VisTypeGraph})
+
+	return data.Frames{frame}
+}
+
+// buildPerformanceSummaryFrame returns one row of statistics per object and counter. Series
+// without samples in the range are omitted.
+func (d *ScomDatasource) buildPerformanceSummaryFrame(series []performanceSeries, rangeEndsNow bool) data.Frames {
+	frame := data.NewFrame("summary")
+
+	var (
+		objectIds          []string
+		objectDisplayNames []string
+		objectPaths        []string
+		counterNames       []string
+		instanceNames      []string
+		groups             []string
+		classes            []string
+		averages           []float64
+		minimums           []float64
+		maximums           []float64
+		lasts              []float64
+	)
+
+	for _, entry := range series {
+		stats, ok := summarizeSeries(entry, rangeEndsNow)
+		if !ok {
+			continue
+		}
+
+		objectIds = append(objectIds, entry.ObjectId)
+		objectDisplayNames = append(objectDisplayNames, entry.ObjectDisplayName)
+		objectPaths = append(objectPaths, entry.ObjectPath)
+		counterNames = append(counterNames, entry.Counter.CounterName)
+		instanceNames = append(instanceNames, entry.Counter.InstanceName)
+		groups = append(groups, scopeGroups(entry.ObjectScope))
+		classes = append(classes, scopeClasses(entry.ObjectScope))
+		averages = append(averages, stats.Average)
+		minimums = append(minimums, stats.Minimum)
+		maximums = append(maximums, stats.Maximum)
+		lasts = append(lasts, stats.Last)
+	}
+
+	frame.Fields = append(frame.Fields,
+		data.NewField("Object id", nil, objectIds),
+		data.NewField("Object display name", nil, objectDisplayNames),
+		data.NewField("Object paths", nil, objectPaths),
+		data.NewField("Counter name", nil, counterNames),
+		data.NewField("Instance name", nil, instanceNames),
+		data.NewField("Group", nil, groups),
+		data.NewField("Class", nil, classes),
+		data.NewField("Average", nil, averages),
+		data.NewField("Minimum", nil, minimums),
+		data.NewField("Maximum", nil, maximums),
+		data.NewField("Last", nil, lasts),
+	)
+
+	frame.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeTable})
+
+	return data.Frames{frame}
+}
+
+// performanceLabels identifies a series by object, path and counter.
+func performanceLabels(entry performanceSeries) data.Labels {
+	return data.Labels{
+		"object":   entry.ObjectDisplayName,
+		"path":     entry.ObjectPath,
+		"counter":  entry.Counter.CounterName,
+		"instance": entry.Counter.InstanceName,
+	}
+}
+
+func (d *ScomDatasource) buildAlertsFrame(alerts models.ScomAlert, resolutionStateNames map[int]string) data.Frames {
 	frame := data.NewFrame("data")
 
 	rowCount := len(alerts.Rows)
@@ -289,6 +565,9 @@ func (d *ScomDatasource) buildAlertsFrame(alerts models.ScomAlert) data.Frames {
 	alertAgesMillis := make([]float64, rowCount)
 	alertRepeatCounts := make([]int64, rowCount)
 	alertDescriptions := make([]string, rowCount)
+	alertTimesRaised := make([]*time.Time, rowCount)
+	alertLastModified := make([]*time.Time, rowCount)
+	alertTimesResolved := make([]*time.Time, rowCount)
 
 	for i, alert := range alerts.Rows {
 		alertIds[i] = alert.ID
@@ -299,8 +578,13 @@ func (d *ScomDatasource) buildAlertsFrame(alerts models.ScomAlert) data.Frames {
 		alertAgesMillis[i] = alert.AgeInMilliseconds
 		alertRepeatCounts[i] = alert.RepeatCount
 		alertDescriptions[i] = alert.Description
+		alertTimesRaised[i] = parseScomTime(alert.TimeRaised)
+		alertLastModified[i] = parseScomTime(alert.LastModified)
+		alertTimesResolved[i] = parseScomTime(alert.TimeResolved)
 	}
 
+	resolutionStates, resolutionStateNameField := resolutionStateFields(alerts, resolutionStateNames)
+
 	frame.Fields = append(frame.Fields,
 		data.NewField("ID", nil, alertIds),
 		data.NewField("Name", nil, alertNames),
@@ -310,6 +594,11 @@ func (d *ScomDatasource) buildAlertsFrame(alerts models.ScomAlert) data.Frames {
 		data.NewField("Age", nil, alertAges),
 		data.NewField("Ages (milliseconds)", nil, alertAgesMillis),
 		data.NewField("Repeat counts", nil, alertRepeatCounts),
+		data.NewField("Time raised", nil, alertTimesRaised),
+		data.NewField("Last modified", nil, alertLastModified),
+		data.NewField("Time resolved", nil, alertTimesResolved),
+		resolutionStates,
+		resolutionStateNameField,
 	)
 
 	return data.Frames{frame}
@@ -332,50 +621,48 @@ func (d *ScomDatasource) buildHealthStateFrame(healthStates []models.MonitoringD
 	var className []string
 	var fullName []string
 	var path []string
-
-	// Map to integer values
-	stateToInt := map[string]int64{
-		"Success": 1,
-		"Warning": 2,
-		"Error":   3,
-	}
+	var groups []string
+	var classes []string
 
 	for _, healthState := range healthStates {
 		// Data from health state request.
 		ids = append(ids, healthState.ObjectID)
-		classHealthStates = append(classHealthStates, healthState.HealthState)
-
-		// Convert to int with default fallback (-1 if not matched)
-		healthStateInt, ok := stateToInt[healthState.HealthState]
-		if !ok {
-			healthStateInt = -1 // Optional: use -1 for unknown states
-		}
-		classHealthStatesInt = append(classHealthStatesInt, int64(healthStateInt))
 
 		alertCount = append(alertCount, strconv.Itoa(healthState.AlertCount))
 
 		// Data from objects request.
 		// Match by id and added to the health state.
-		if objData, ok := objectDataMap[healthState.ObjectID]; ok {
+		objData, ok := objectDataMap[healthState.ObjectID]
+		if ok {
 			displayName = append(displayName, objData.DisplayName)
 			className = append(className, objData.ClassName)
 			fullName = append(fullName, objData.FullName)
 			path = append(path, objData.Path)
+			groups = append(groups, scopeGroups(objData.Scope))
+			classes = append(classes, scopeClasses(objData.Scope))
 		} else {
 			// Log missing object
 			backend.Logger.Warn("Missing objectData for health state", "objectID", healthState.ObjectID)
 		}
+
+		code := objectHealthStateCode(healthState.HealthState, objData)
+		classHealthStates = append(classHealthStates, healthStateName(code, healthState.HealthState))
+		classHealthStatesInt = append(classHealthStatesInt, code)
 	}
 
+	healthStateField, healthStateIntField := healthStateFields(classHealthStates, classHealthStatesInt)
+
 	frame.Fields = append(frame.Fields,
 		data.NewField("Id", nil, ids),
-		data.NewField("Health state", nil, classHealthStates),
-		data.NewField("Health state int", nil, classHealthStatesInt),
+		healthStateField,
+		healthStateIntField,
 		data.NewField("Alert count", nil, alertCount),
 		data.NewField("Class instance name", nil, displayName),
 		data.NewField("Class name", nil, className),
 		data.NewField("Full name", nil, fullName),
 		data.NewField("Path", nil, path),
+		data.NewField("Group", nil, groups),
+		data.NewField("Class", nil, classes),
 	)
 
 	frame.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeTable})
@@ -391,24 +678,35 @@ func (d *ScomDatasource) buildHealthStateGroupFrame(healthStateGroup models.Stat
 	// Preallocate slices for efficiency
 	ids := make([]string, rowCount)
 	healthStates := make([]string, rowCount)
+	healthStatesInt := make([]int64, rowCount)
 	displayNames := make([]string, rowCount)
 	paths := make([]string, rowCount)
 	maintenanceModes := make([]string, rowCount)
+	groups := make([]string, rowCount)
+	classes := make([]string, rowCount)
 
 	for i, value := range healthStateGroup.Rows {
 		ids[i] = value.ID
-		healthStates[i] = value.HealthState
+		healthStatesInt[i] = objectHealthStateCode(value.HealthState, value)
+		healthStates[i] = healthStateName(healthStatesInt[i], value.HealthState)
 		displayNames[i] = value.DisplayName
 		paths[i] = value.Path
 		maintenanceModes[i] = value.MaintenanceMode
+		groups[i] = scopeGroups(value.Scope)
+		classes[i] = scopeClasses(value.Scope)
 	}
 
+	healthStateField, healthStateIntField := healthStateFields(healthStates, healthStatesInt)
+
 	frame.Fields = append(frame.Fields,
 		data.NewField("Id", nil, ids),
-		data.NewField("Health state", nil, healthStates),
+		healthStateField,
+		healthStateIntField,
 		data.NewField("Name", nil, displayNames),
 		data.NewField("Path", nil, paths),
 		data.NewField("Maintenance mode", nil, maintenanceModes),
+		data.NewField("Group", nil, groups),
+		data.NewField("Class", nil, classes),
 	)
 
 	frame.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeTable})
@@ -427,28 +725,40 @@ func (d *ScomDatasource) CallResource(ctx context.Context, req *backend.CallReso
 
 	handlers := map[string]func() (interface{}, error){
 		"getClasses": func() (interface{}, error) {
-			return d.client.GetClassesByDisplayName(query.Get("query"))
+			return d.client.GetClassesByDisplayName(ctx, query.Get("query"))
 		},
 		"getObjects": func() (interface{}, error) {
-			return d.client.GetObjectsByClass(query.Get("className"))
+			return d.client.GetObjectsByClass(ctx, query.Get("className"))
 		},
 		"getCounters": func() (interface{}, error) {
-			return d.client.GetPerformanceCounters(query["entityIds"])
+			return d.client.GetPerformanceCounters(ctx, query["entityIds"])
 		},
 		"getObjectsHealthState": func() (interface{}, error) {
-			return d.client.GetObjectsByClass(query.Get("selectedClassNameHealthState"))
+			return d.client.GetObjectsByClass(ctx, query.Get("selectedClassNameHealthState"))
 		},
 		"getGroups": func() (interface{}, error) {
-			return d.client.GetGroups(query.Get("groupQueryCriteria"))
+			return d.client.GetGroups(ctx, query.Get("groupQueryCriteria"))
 		},
 		"getObjectsByGroup": func() (interface{}, error) {
-			return d.client.GetStateData(query.Get("groupId"), query.Get("classIdGroup"))
+			return d.client.GetStateData(ctx, query.Get("groupId"), query.Get("classIdGroup"))
 		},
 		"getClassesForObject": func() (interface{}, error) {
-			return d.client.GetClassesForObject(query.Get(("objectId")))
+			return d.client.GetClassesForObject(ctx, query.Get(("objectId")))
 		},
 	}
 
+	for path := range alertActions {
+		handlers[path] = func() (interface{}, error) {
+			return d.handleAlertAction(ctx, req)
+		}
+	}
+
+	for path := range maintenanceActions {
+		handlers[path] = func() (interface{}, error) {
+			return d.handleMaintenanceAction(ctx, req)
+		}
+	}
+
 	handler, exists := handlers[req.Path]
 	if !exists {
 		return sender.Send(&backend.CallResourceResponse{
@@ -459,10 +769,18 @@ func (d *ScomDatasource) CallResource(ctx context.Context, req *backend.CallReso
 
 	result, err := handler()
 	if err != nil {
-		return sender.Send(&backend.CallResourceResponse{
+		response := &backend.CallResourceResponse{
 			Status: http.StatusBadRequest,
 			Body:   []byte(fmt.Sprintf("error: %v", err.Error())),
-		})
+		}
+
+		var statusErr *statusError
+		if errors.As(err, &statusErr) {
+			response.Status = statusErr.status
+			response.Headers = statusErr.headers
+		}
+
+		return sender.Send(response)
 	}
 
 	data, err := json.Marshal(result)
@@ -480,18 +798,20 @@ func (d *ScomDatasource) CallResource(ctx context.Context, req *backend.CallReso
 }
 
 // This function is called when user enters name, password and url for using the plugin.
-func (d *ScomDatasource) CheckHealth(_ context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+func (d *ScomDatasource) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
 	var status = backend.HealthStatusOk
 	var message = "Data source is working"
 
 	settings, err := models.LoadPluginSettings(*req.PluginContext.DataSourceInstanceSettings)
 	if err != nil {
-		status = backend.HealthStatusError
-		message = "Loading plugin settings failed, check logs for more details"
 		log.Println("ERROR: ", err)
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: "Loading plugin settings failed, check logs for more details",
+		}, nil
 	}
 
-	_, err = Authenticate(settings.Url, settings.UserName, settings.Secrets.Password, settings.IsSkipTlsVerifyCheck)
+	_, err = Authenticate(ctx, settings)
 	if err != nil {
 		status = backend.HealthStatusError
 		message = "Wrong credentials for SCOM, check logs for more details"
@@ -518,12 +838,48 @@ func ParseQuery(jsonData []byte) (interface{}, error) {
 			return nil, err
 		}
 		return q, nil
+	case "healthExplorer":
+		var q models.HealthExplorerQuery
+		if err := json.Unmarshal(jsonData, &q); err != nil {
+			return nil, err
+		}
+		return q, nil
+	case "stateHistory":
+		var q models.StateHistoryQuery
+		if err := json.Unmarshal(jsonData, &q); err != nil {
+			return nil, err
+		}
+		return q, nil
+	case "availability":
+		var q models.AvailabilityQuery
+		if err := json.Unmarshal(jsonData, &q); err != nil {
+			return nil, err
+		}
+		return q, nil
 	case "alerts":
 		var q models.AlertQuery
 		if err := json.Unmarshal(jsonData, &q); err != nil {
 			return nil, err
 		}
 		return q, nil
+	case "alertAggregation":
+		var q models.AlertAggregationQuery
+		if err := json.Unmarshal(jsonData, &q); err != nil {
+			return nil, err
+		}
+		return q, nil
+	case "alertAnnotations":
+		var q models.AlertAnnotationQuery
+		if err := json.Unmarshal(jsonData, &q); err != nil {
+			return nil, err
+		}
+		return q, nil
+	case "maintenance":
+		var q models.MaintenanceQuery
+		if err := json.Unmarshal(jsonData, &q); err != nil {
+			return nil, err
+		}
+		return q, nil
 	case "performance":
 		var q models.PerformanceQuery
 		if err := json.Unmarshal(jsonData, &q); err != nil {
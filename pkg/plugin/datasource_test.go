@@ -2,9 +2,12 @@ package plugin
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
 )
 
 func TestQueryData(t *testing.T) {
@@ -26,3 +29,92 @@ func TestQueryData(t *testing.T) {
 		t.Fatal("QueryData must return a response")
 	}
 }
+
+func TestBuildPerformanceFrameMultipleCounters(t *testing.T) {
+	var entry models.PerformanceResponse
+	err := json.Unmarshal([]byte(`{
+		"datasets": [
+			{"id": "b", "data": {"2024-01-01T00:00:00Z": 512, "2024-01-01T00:05:00Z": 480}},
+			{"id": "a", "data": {"2024-01-01T00:00:00Z": 12.5}}
+		],
+		"legends": {"rows": [
+			{"id": "a", "performanceobject": "Processor Information", "performancecounter": "% Processor Time", "performanceinstance": "_Total"},
+			{"id": "b", "performanceobject": "Memory", "performancecounter": "Available MBytes", "performanceinstance": ""}
+		]}
+	}`), &entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.ObjectDisplayName = "web01"
+
+	ds := ScomDatasource{}
+	frames := ds.buildPerformanceFrame(toPerformanceSeries([]models.PerformanceResponse{entry}, []models.PerformanceCounter{
+		{ObjectName: "Processor Information", CounterName: "% Processor Time", InstanceName: "_Total"},
+		{ObjectName: "Memory", CounterName: "Available MBytes"},
+	}))
+
+	if len(frames) != 2 {
+		t.Fatalf("expected a frame per counter, got %d", len(frames))
+	}
+	if frames[0].Name != "web01 - Available MBytes" || frames[0].Rows() != 2 {
+		t.Errorf("unexpected first frame %q with %d rows", frames[0].Name, frames[0].Rows())
+	}
+	if frames[1].Name != "web01 - % Processor Time (_Total)" || frames[1].Rows() != 1 {
+		t.Errorf("unexpected second frame %q with %d rows", frames[1].Name, frames[1].Rows())
+	}
+}
+
+func TestBuildPerformanceWideFrame(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	series := []performanceSeries{
+		{
+			Name:              "web01",
+			ObjectDisplayName: "web01",
+			Counter:           models.PerformanceCounter{CounterName: "% Processor Time", InstanceName: "_Total"},
+			Samples:           []sample{{Time: base, Value: 1}, {Time: base.Add(time.Minute), Value: 2}},
+		},
+		{
+			Name:              "web02",
+			ObjectDisplayName: "web02",
+			Counter:           models.PerformanceCounter{CounterName: "% Processor Time", InstanceName: "_Total"},
+			Samples:           []sample{{Time: base.Add(time.Minute), Value: 3}},
+		},
+	}
+
+	ds := ScomDatasource{}
+	frames := ds.buildPerformanceWideFrame(series)
+	if len(frames) != 1 {
+		t.Fatalf("expected a single frame, got %d", len(frames))
+	}
+
+	frame := frames[0]
+	if len(frame.Fields) != 3 || frame.Rows() != 2 {
+		t.Fatalf("unexpected frame shape: %d fields, %d rows", len(frame.Fields), frame.Rows())
+	}
+	if frame.Fields[2].Labels["object"] != "web02" || frame.Fields[2].Config.DisplayNameFromDS != "web02" {
+		t.Errorf("unexpected labels %v", frame.Fields[2].Labels)
+	}
+	if v, ok := frame.Fields[2].ConcreteAt(0); ok {
+		t.Errorf("expected null for missing sample, got %v", v)
+	}
+	if v, _ := frame.Fields[2].ConcreteAt(1); v != 3.0 {
+		t.Errorf("expected 3 at second timestamp, got %v", v)
+	}
+}
+
+func TestCheckHealthInvalidSettings(t *testing.T) {
+	ds := ScomDatasource{}
+
+	result, err := ds.CheckHealth(context.Background(), &backend.CheckHealthRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: []byte(`{"authMode": "kerberos"}`)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Status != backend.HealthStatusError {
+		t.Errorf("expected error status, got %v", result.Status)
+	}
+}
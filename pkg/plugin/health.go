@@ -0,0 +1,147 @@
+package plugin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+// Numeric health state codes returned by every state frame. Success, warning and error keep the
+// codes state frames have always used.
+const (
+	healthStateUnknown       int64 = -1
+	healthStateUninitialized int64 = 0
+	healthStateSuccess       int64 = 1
+	healthStateWarning       int64 = 2
+	healthStateError         int64 = 3
+	healthStateNotMonitored  int64 = 4
+	healthStateMaintenance   int64 = 5
+	// healthStateUnavailable is an object whose agent or management server stopped reporting,
+	// shown grey in the SCOM console.
+	healthStateUnavailable int64 = 6
+)
+
+// healthStates names and colours every health state code, in display order.
+var healthStates = []struct {
+	code  int64
+	name  string
+	color string
+}{
+	{healthStateSuccess, "Success", "green"},
+	{healthStateWarning, "Warning", "orange"},
+	{healthStateError, "Error", "red"},
+	{healthStateMaintenance, "Maintenance", "blue"},
+	{healthStateUninitialized, "Uninitialized", "#a1a1a1"},
+	{healthStateNotMonitored, "NotMonitored", "#d8d9da"},
+	{healthStateUnavailable, "Unavailable", "#5f5f5f"},
+	{healthStateUnknown, "Unknown", "purple"},
+}
+
+// Health state names as SCOM returns them, lower cased, by code.
+var healthStateNames = map[string]int64{
+	"success":       healthStateSuccess,
+	"healthy":       healthStateSuccess,
+	"warning":       healthStateWarning,
+	"error":         healthStateError,
+	"critical":      healthStateError,
+	"uninitialized": healthStateUninitialized,
+	"notmonitored":  healthStateNotMonitored,
+	"not monitored": healthStateNotMonitored,
+	"maintenance":   healthStateMaintenance,
+	"unavailable":   healthStateUnavailable,
+}
+
+// SCOM health state enumeration values, returned by some endpoints instead of names.
+var scomHealthStateValues = map[int]int64{
+	0: healthStateUninitialized,
+	1: healthStateSuccess,
+	2: healthStateWarning,
+	3: healthStateError,
+}
+
+// healthStateCode returns the code of a health state returned by SCOM, by name or number. An empty
+// state is not monitored, anything unrecognised is unknown.
+func healthStateCode(state string) int64 {
+	state = strings.ToLower(strings.TrimSpace(state))
+	if state == "" {
+		return healthStateNotMonitored
+	}
+
+	if code, ok := healthStateNames[state]; ok {
+		return code
+	}
+
+	if value, err := strconv.Atoi(state); err == nil {
+		if code, ok := scomHealthStateValues[value]; ok {
+			return code
+		}
+	}
+
+	return healthStateUnknown
+}
+
+// objectHealthStateCode returns the code of the health state of an object. Objects in maintenance
+// or that stopped reporting are shown as such, whatever the state of their monitors.
+func objectHealthStateCode(state string, object models.MonitoringObject) int64 {
+	switch {
+	case isTrue(object.MaintenanceMode):
+		return healthStateMaintenance
+	case strings.EqualFold(strings.TrimSpace(string(object.IsAvailable)), "false"):
+		return healthStateUnavailable
+	}
+
+	return healthStateCode(state)
+}
+
+// isTrue reports whether a flag returned by SCOM as text is set.
+func isTrue(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "1":
+		return true
+	}
+
+	return false
+}
+
+// healthStateName returns the display name of a health state code. Unknown states keep the name
+// SCOM returned.
+func healthStateName(code int64, state string) string {
+	if code == healthStateUnknown {
+		return state
+	}
+
+	for _, s := range healthStates {
+		if s.code == code {
+			return s.name
+		}
+	}
+
+	return state
+}
+
+// healthStateConfig maps health state codes, and the names of the states, to their display name
+// and colour. It applies to both numeric and text health state fields.
+func healthStateConfig() *data.FieldConfig {
+	mapper := data.ValueMapper{}
+	for i, state := range healthStates {
+		result := data.ValueMappingResult{Text: state.name, Color: state.color, Index: i}
+		mapper[strconv.FormatInt(state.code, 10)] = result
+		mapper[state.name] = result
+	}
+
+	return &data.FieldConfig{Mappings: data.ValueMappings{mapper}}
+}
+
+// healthStateFields returns a text field holding the health state names and a numeric field
+// holding their codes, both with the health state mappings.
+func healthStateFields(names []string, codes []int64) (*data.Field, *data.Field) {
+	nameField := data.NewField("Health state", nil, names)
+	nameField.SetConfig(healthStateConfig())
+
+	codeField := data.NewField("Health state int", nil, codes)
+	codeField.SetConfig(healthStateConfig())
+
+	return nameField, codeField
+}
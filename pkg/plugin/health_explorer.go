@@ -0,0 +1,211 @@
+package plugin
+
+import (
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+// monitorNode is a node of the monitor rollup tree of an object. The object itself is the root
+// node and has no parent.
+type monitorNode struct {
+	ID                 string
+	ParentID           string
+	Object             models.MonitoringObject
+	MonitorID          string
+	MonitorName        string
+	MonitorDisplayName string
+	HealthState        string
+	LastModified       *time.Time
+	Depth              int64
+}
+
+// monitorTree flattens the monitor rollup trees of the objects, parents before their children.
+// Node ids are prefixed by the object id, as the same monitor appears under every object.
+func monitorTree(healthStates []models.MonitoringDataResponse, objects []models.MonitoringObject) []monitorNode {
+	objectMap := make(map[string]models.MonitoringObject, len(objects))
+	for _, object := range objects {
+		objectMap[object.ID] = object
+	}
+
+	var nodes []monitorNode
+
+	var walk func(object models.MonitoringObject, parentID string, children []models.ChildNodeData, depth int64)
+	walk = func(object models.MonitoringObject, parentID string, children []models.ChildNodeData, depth int64) {
+		for _, child := range children {
+			id := child.ID
+			if id == "" {
+				id = child.MonitorID
+			}
+			id = object.ID + "/" + id
+
+			nodes = append(nodes, monitorNode{
+				ID:                 id,
+				ParentID:           parentID,
+				Object:             object,
+				MonitorID:          child.MonitorID,
+				MonitorName:        child.MonitorName,
+				MonitorDisplayName: child.MonitorDisplayName,
+				HealthState:        child.HealthState,
+				LastModified:       parseScomTime(child.LastTimeModified),
+				Depth:              depth,
+			})
+
+			walk(object, id, child.ChildNodeDatas, depth+1)
+		}
+	}
+
+	for _, healthState := range healthStates {
+		object, ok := objectMap[healthState.ObjectID]
+		if !ok {
+			object = models.MonitoringObject{ID: healthState.ObjectID}
+		}
+
+		nodes = append(nodes, monitorNode{
+			ID:                 object.ID,
+			Object:             object,
+			MonitorDisplayName: object.DisplayName,
+			HealthState:        healthState.HealthState,
+		})
+
+		walk(object, object.ID, healthState.ChildNodeDatas, 1)
+	}
+
+	return nodes
+}
+
+// buildHealthExplorerFrames returns the monitor trees as a parent/child table, followed by the
+// nodes and edges frames of the node graph panel.
+func (d *ScomDatasource) buildHealthExplorerFrames(healthStates []models.MonitoringDataResponse, objects []models.MonitoringObject) data.Frames {
+	nodes := monitorTree(healthStates, objects)
+	rowCount := len(nodes)
+
+	ids := make([]string, rowCount)
+	parentIds := make([]string, rowCount)
+	objectIds := make([]string, rowCount)
+	objectNames := make([]string, rowCount)
+	monitorIds := make([]string, rowCount)
+	monitorNames := make([]string, rowCount)
+	monitorDisplayNames := make([]string, rowCount)
+	healthStatesText := make([]string, rowCount)
+	healthStatesInt := make([]int64, rowCount)
+	lastModified := make([]*time.Time, rowCount)
+	depths := make([]int64, rowCount)
+
+	for i, node := range nodes {
+		ids[i] = node.ID
+		parentIds[i] = node.ParentID
+		objectIds[i] = node.Object.ID
+		objectNames[i] = node.Object.DisplayName
+		monitorIds[i] = node.MonitorID
+		monitorNames[i] = node.MonitorName
+		monitorDisplayNames[i] = node.MonitorDisplayName
+		healthStatesInt[i] = nodeHealthStateCode(node)
+		healthStatesText[i] = healthStateName(healthStatesInt[i], node.HealthState)
+		lastModified[i] = node.LastModified
+		depths[i] = node.Depth
+	}
+
+	healthStateField, healthStateIntField := healthStateFields(healthStatesText, healthStatesInt)
+
+	table := data.NewFrame("healthExplorer",
+		data.NewField("Id", nil, ids),
+		data.NewField("Parent id", nil, parentIds),
+		data.NewField("Object id", nil, objectIds),
+		data.NewField("Object display name", nil, objectNames),
+		data.NewField("Monitor id", nil, monitorIds),
+		data.NewField("Monitor name", nil, monitorNames),
+		data.NewField("Monitor display name", nil, monitorDisplayNames),
+		healthStateField,
+		healthStateIntField,
+		data.NewField("Depth", nil, depths),
+		data.NewField("Last modified", nil, lastModified),
+	)
+	table.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeTable})
+
+	return data.Frames{table, buildMonitorNodesFrame(nodes), buildMonitorEdgesFrame(nodes)}
+}
+
+// nodeHealthStateCode returns the health state code of a node. Object nodes show maintenance and
+// unavailability of the object, monitor nodes the state of the monitor.
+func nodeHealthStateCode(node monitorNode) int64 {
+	if node.ParentID == "" {
+		return objectHealthStateCode(node.HealthState, node.Object)
+	}
+
+	return healthStateCode(node.HealthState)
+}
+
+func buildMonitorNodesFrame(nodes []monitorNode) *data.Frame {
+	rowCount := len(nodes)
+
+	ids := make([]string, rowCount)
+	titles := make([]string, rowCount)
+	subtitles := make([]string, rowCount)
+	mainStats := make([]string, rowCount)
+	codes := make([]int64, rowCount)
+
+	for i, node := range nodes {
+		ids[i] = node.ID
+		titles[i] = node.MonitorDisplayName
+		if titles[i] == "" {
+			titles[i] = node.MonitorName
+		}
+		subtitles[i] = node.Object.DisplayName
+		codes[i] = nodeHealthStateCode(node)
+		mainStats[i] = healthStateName(codes[i], node.HealthState)
+	}
+
+	frame := data.NewFrame("nodes",
+		data.NewField("id", nil, ids),
+		data.NewField("title", nil, titles),
+		data.NewField("subtitle", nil, subtitles),
+		data.NewField("mainstat", nil, mainStats),
+	)
+
+	// An arc per health state colours every node by its state.
+	for _, state := range healthStates {
+		values := make([]float64, rowCount)
+		for i, code := range codes {
+			if code == state.code {
+				values[i] = 1
+			}
+		}
+
+		field := data.NewField("arc__"+strings.ToLower(state.name), nil, values)
+		field.SetConfig(&data.FieldConfig{
+			DisplayName: state.name,
+			Color:       map[string]interface{}{"mode": "fixed", "fixedColor": state.color},
+		})
+		frame.Fields = append(frame.Fields, field)
+	}
+
+	frame.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeNodeGraph})
+
+	return frame
+}
+
+func buildMonitorEdgesFrame(nodes []monitorNode) *data.Frame {
+	var ids, sources, targets []string
+
+	for _, node := range nodes {
+		if node.ParentID == "" {
+			continue
+		}
+
+		ids = append(ids, node.ParentID+"->"+node.ID)
+		sources = append(sources, node.ParentID)
+		targets = append(targets, node.ID)
+	}
+
+	frame := data.NewFrame("edges",
+		data.NewField("id", nil, ids),
+		data.NewField("source", nil, sources),
+		data.NewField("target", nil, targets),
+	)
+	frame.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeNodeGraph})
+
+	return frame
+}
@@ -0,0 +1,67 @@
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+func TestBuildHealthExplorerFrames(t *testing.T) {
+	var state models.MonitoringDataResponse
+	err := json.Unmarshal([]byte(`{
+		"objectId": "obj",
+		"healthState": "Error",
+		"childNodeDatas": [
+			{"id": "avail", "monitorName": "System.Health.AvailabilityState", "monitorDisplayName": "Availability", "healthState": "Success"},
+			{"id": "perf", "monitorName": "System.Health.PerformanceState", "monitorDisplayName": "Performance", "healthState": "Error", "childNodeDatas": [
+				{"id": "cpu", "monitorName": "Cpu.Utilization", "healthState": "Error", "lastTimeModified": "2024-01-10T12:00:00Z"}
+			]}
+		]
+	}`), &state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds := ScomDatasource{}
+	frames := ds.buildHealthExplorerFrames([]models.MonitoringDataResponse{state}, []models.MonitoringObject{{ID: "obj", DisplayName: "web01"}})
+
+	if len(frames) != 3 {
+		t.Fatalf("expected table, nodes and edges frames, got %d", len(frames))
+	}
+
+	table := frames[0]
+	if table.Rows() != 4 {
+		t.Fatalf("expected 4 nodes, got %d", table.Rows())
+	}
+
+	// The unit monitor is a child of the performance rollup, which is a child of the object.
+	if id, parent := table.Fields[0].At(3), table.Fields[1].At(3); id != "obj/cpu" || parent != "obj/perf" {
+		t.Errorf("unexpected node %v with parent %v", id, parent)
+	}
+	if parent := table.Fields[1].At(2); parent != "obj" {
+		t.Errorf("unexpected parent of rollup monitor %v", parent)
+	}
+	if depth := table.Fields[9].At(3); depth != int64(2) {
+		t.Errorf("unexpected depth %v", depth)
+	}
+	if code := table.Fields[8].At(3); code != healthStateError {
+		t.Errorf("unexpected health state code %v", code)
+	}
+	if _, ok := table.Fields[10].ConcreteAt(3); !ok {
+		t.Errorf("expected last modified time of unit monitor")
+	}
+
+	if edges := frames[2]; edges.Rows() != 3 {
+		t.Errorf("expected 3 edges, got %d", edges.Rows())
+	}
+
+	// Cpu has no display name and is titled by its monitor name, with an error arc.
+	nodes := frames[1]
+	if title := nodes.Fields[1].At(3); title != "Cpu.Utilization" {
+		t.Errorf("unexpected title %v", title)
+	}
+	if arc := nodes.Fields[6].At(3); arc != float64(1) {
+		t.Errorf("expected error arc, got %v", arc)
+	}
+}
@@ -0,0 +1,64 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+func TestHealthStateCode(t *testing.T) {
+	for state, want := range map[string]int64{
+		"Success":       healthStateSuccess,
+		"warning":       healthStateWarning,
+		"Error":         healthStateError,
+		"Uninitialized": healthStateUninitialized,
+		"NotMonitored":  healthStateNotMonitored,
+		"":              healthStateNotMonitored,
+		"3":             healthStateError,
+		"Exploded":      healthStateUnknown,
+	} {
+		if got := healthStateCode(state); got != want {
+			t.Errorf("%q: got %d, want %d", state, got, want)
+		}
+	}
+
+	if got := objectHealthStateCode("Error", models.MonitoringObject{MaintenanceMode: "True"}); got != healthStateMaintenance {
+		t.Errorf("expected maintenance, got %d", got)
+	}
+	if got := objectHealthStateCode("Success", models.MonitoringObject{IsAvailable: "false"}); got != healthStateUnavailable {
+		t.Errorf("expected unavailable, got %d", got)
+	}
+}
+
+func TestBuildHealthStateGroupFrame(t *testing.T) {
+	ds := ScomDatasource{}
+	frame := ds.buildHealthStateGroupFrame(models.StateDataResponse{Rows: []models.MonitoringObject{
+		{ID: "1", HealthState: "Success"},
+		{ID: "2", HealthState: "Warning", MaintenanceMode: "true"},
+		{ID: "3", HealthState: "Uninitialized"},
+	}})[0]
+
+	names, codes := frame.Fields[1], frame.Fields[2]
+	if codes.Name != "Health state int" {
+		t.Fatalf("expected numeric health state field, got %s", codes.Name)
+	}
+
+	for i, want := range []struct {
+		name string
+		code int64
+	}{
+		{"Success", healthStateSuccess},
+		{"Maintenance", healthStateMaintenance},
+		{"Uninitialized", healthStateUninitialized},
+	} {
+		if names.At(i) != want.name || codes.At(i) != want.code {
+			t.Errorf("row %d: got %v (%v), want %s (%d)", i, names.At(i), codes.At(i), want.name, want.code)
+		}
+	}
+
+	mapper, ok := codes.Config.Mappings[0].(data.ValueMapper)
+	if !ok || mapper["5"].Text != "Maintenance" || mapper["Error"].Color != "red" {
+		t.Errorf("unexpected value mappings %+v", codes.Config.Mappings)
+	}
+}
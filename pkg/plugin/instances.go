@@ -0,0 +1,152 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+// selectInstances validates that a selection names classes, groups or explicit instances, and
+// resolves it into the monitoring objects it covers.
+func (d *ScomDatasource) selectInstances(ctx context.Context, selection models.ObjectSelection) ([]models.MonitoringObject, error) {
+	if len(selection.Classes) == 0 && len(selection.Groups) == 0 && (len(selection.Instances) == 0 || selection.Instances[0].ID == "*") {
+		return nil, fmt.Errorf("required property 'classes' or 'groups' is missing or empty")
+	}
+
+	return d.resolveInstances(ctx, selection.Classes, selection.Groups, selection.Instances)
+}
+
+// resolveInstances expands the classes, groups and instances of a query into the monitoring objects
+// it covers. Every selected group is combined with every selected class, a wildcard or empty
+// instance list selects all instances of every class, and objects reached through more than one
+// group or class are returned once with all of them recorded in their scope.
+func (d *ScomDatasource) resolveInstances(ctx context.Context, classes []models.MonitoringClass, groups []models.ScomGroup, instances []models.MonitoringObject) ([]models.MonitoringObject, error) {
+	type scopeRequest struct {
+		group models.ScomGroup
+		class models.MonitoringClass
+	}
+
+	var resolved [][]models.MonitoringObject
+
+	switch {
+	case len(groups) > 0:
+		// Groups without classes return every member of the group.
+		scopeClasses := classes
+		if len(scopeClasses) == 0 {
+			scopeClasses = []models.MonitoringClass{{}}
+		}
+
+		var requests []scopeRequest
+		for _, group := range groups {
+			for _, class := range scopeClasses {
+				requests = append(requests, scopeRequest{group: group, class: class})
+			}
+		}
+
+		var err error
+		resolved, err = fanOut(ctx, d.client.pool, requests, func(ctx context.Context, r scopeRequest) ([]models.MonitoringObject, error) {
+			states, err := d.client.GetStateData(ctx, r.group.ID, r.class.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			return withScope(states.Rows, r.group.DisplayName, r.class.DisplayName), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	case len(instances) == 0 || instances[0].ID == "*":
+		var err error
+		resolved, err = fanOut(ctx, d.client.pool, classes, func(ctx context.Context, class models.MonitoringClass) ([]models.MonitoringObject, error) {
+			objects, err := d.client.GetObjectsByClass(ctx, class.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			return withScope(objects, "", class.DisplayName), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		className := ""
+		if len(classes) == 1 {
+			className = classes[0].DisplayName
+		}
+
+		resolved = [][]models.MonitoringObject{withScope(instances, "", className)}
+	}
+
+	return mergeInstances(resolved), nil
+}
+
+// withScope returns a copy of objects with group and class added to their scope.
+func withScope(objects []models.MonitoringObject, group, class string) []models.MonitoringObject {
+	result := make([]models.MonitoringObject, len(objects))
+	for i, object := range objects {
+		object.Scope = models.ObjectScope{}
+		if group != "" {
+			object.Scope.Groups = []string{group}
+		}
+		if class != "" {
+			object.Scope.Classes = []string{class}
+		} else if object.ClassName != "" {
+			object.Scope.Classes = []string{object.ClassName}
+		}
+		result[i] = object
+	}
+
+	return result
+}
+
+// mergeInstances flattens object lists, keeping the first occurrence of every object id and merging
+// the scopes of duplicates.
+func mergeInstances(lists [][]models.MonitoringObject) []models.MonitoringObject {
+	var result []models.MonitoringObject
+	index := map[string]int{}
+
+	for _, objects := range lists {
+		for _, object := range objects {
+			i, exists := index[object.ID]
+			if !exists {
+				index[object.ID] = len(result)
+				result = append(result, object)
+				continue
+			}
+
+			result[i].Scope.Groups = appendUnique(result[i].Scope.Groups, object.Scope.Groups...)
+			result[i].Scope.Classes = appendUnique(result[i].Scope.Classes, object.Scope.Classes...)
+		}
+	}
+
+	return result
+}
+
+func appendUnique(values []string, more ...string) []string {
+	for _, value := range more {
+		exists := false
+		for _, v := range values {
+			if v == value {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// scopeGroups formats the groups an object was selected through for a frame field.
+func scopeGroups(scope models.ObjectScope) string {
+	return strings.Join(scope.Groups, ", ")
+}
+
+// scopeClasses formats the classes an object was selected through for a frame field.
+func scopeClasses(scope models.ObjectScope) string {
+	return strings.Join(scope.Classes, ", ")
+}
@@ -0,0 +1,51 @@
+package plugin
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+func TestMergeInstancesDeduplicatesByID(t *testing.T) {
+	sql := models.MonitoringObject{ID: "1", DisplayName: "sql01"}
+	iis := models.MonitoringObject{ID: "2", DisplayName: "web01"}
+
+	merged := mergeInstances([][]models.MonitoringObject{
+		withScope([]models.MonitoringObject{sql, iis}, "Production", "SQL Server"),
+		withScope([]models.MonitoringObject{sql}, "DR", "SQL Server"),
+		withScope([]models.MonitoringObject{iis}, "Production", "IIS Server"),
+	})
+
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 objects, got %d", len(merged))
+	}
+	if merged[0].ID != "1" || merged[1].ID != "2" {
+		t.Fatalf("unexpected order: %s, %s", merged[0].ID, merged[1].ID)
+	}
+
+	want := models.ObjectScope{Groups: []string{"Production", "DR"}, Classes: []string{"SQL Server"}}
+	if !reflect.DeepEqual(merged[0].Scope, want) {
+		t.Errorf("unexpected scope %+v, want %+v", merged[0].Scope, want)
+	}
+	if got := scopeClasses(merged[1].Scope); got != "SQL Server, IIS Server" {
+		t.Errorf("unexpected classes %q", got)
+	}
+}
+
+func TestSelectInstancesRequiresSelection(t *testing.T) {
+	d := &ScomDatasource{}
+
+	if _, err := d.selectInstances(context.Background(), models.ObjectSelection{Instances: []models.MonitoringObject{{ID: "*"}}}); err == nil {
+		t.Fatal("expected an error for a wildcard without classes or groups")
+	}
+
+	instances, err := d.selectInstances(context.Background(), models.ObjectSelection{Instances: []models.MonitoringObject{{ID: "1"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(instances) != 1 || instances[0].ID != "1" {
+		t.Errorf("unexpected instances %+v", instances)
+	}
+}
@@ -0,0 +1,111 @@
+package plugin
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+// maintenanceWindowEnd returns the end of a maintenance window. Windows still in progress end at
+// their scheduled end time.
+func maintenanceWindowEnd(window models.MaintenanceWindow) *time.Time {
+	if end := parseScomTime(window.EndTime); end != nil {
+		return end
+	}
+
+	return parseScomTime(window.ScheduledEndTime)
+}
+
+// maintenanceText describes a maintenance window by its reason and comment.
+func maintenanceText(window models.MaintenanceWindow) string {
+	var lines []string
+	if window.Reason != "" {
+		lines = append(lines, "Reason: "+window.Reason)
+	}
+	if window.Comments != "" {
+		lines = append(lines, window.Comments)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// buildMaintenanceFrame returns the maintenance windows overlapping timeRange as annotation
+// regions, ordered by start time. Windows without a start time are skipped.
+func (d *ScomDatasource) buildMaintenanceFrame(windows []models.MaintenanceWindow, timeRange backend.TimeRange) data.Frames {
+	type region struct {
+		window     models.MaintenanceWindow
+		start, end time.Time
+	}
+
+	var regions []region
+	for _, window := range windows {
+		start := parseScomTime(window.StartTime)
+		if start == nil || start.After(timeRange.To) {
+			continue
+		}
+
+		// Windows without any end time are open ended, so they last until the end of the range.
+		end := timeRange.To
+		if windowEnd := maintenanceWindowEnd(window); windowEnd != nil {
+			end = *windowEnd
+		}
+		if end.Before(timeRange.From) {
+			continue
+		}
+
+		regions = append(regions, region{window: window, start: *start, end: end})
+	}
+
+	sort.SliceStable(regions, func(i, j int) bool {
+		return regions[i].start.Before(regions[j].start)
+	})
+
+	rowCount := len(regions)
+
+	times := make([]time.Time, rowCount)
+	ends := make([]time.Time, rowCount)
+	titles := make([]string, rowCount)
+	texts := make([]string, rowCount)
+	tags := make([]string, rowCount)
+	objectIds := make([]string, rowCount)
+	reasons := make([]string, rowCount)
+	comments := make([]string, rowCount)
+	users := make([]string, rowCount)
+
+	for i, r := range regions {
+		times[i] = r.start
+		ends[i] = r.end
+		titles[i] = "Maintenance: " + r.window.Object.DisplayName
+		texts[i] = maintenanceText(r.window)
+		objectIds[i] = r.window.Object.ID
+		reasons[i] = r.window.Reason
+		comments[i] = r.window.Comments
+		users[i] = r.window.User
+
+		regionTags := []string{"maintenance"}
+		for _, value := range []string{r.window.Object.DisplayName, r.window.Reason} {
+			if value != "" {
+				regionTags = append(regionTags, strings.ReplaceAll(value, ",", " "))
+			}
+		}
+		tags[i] = strings.Join(regionTags, ",")
+	}
+
+	frame := data.NewFrame("maintenance",
+		data.NewField("time", nil, times),
+		data.NewField("timeEnd", nil, ends),
+		data.NewField("title", nil, titles),
+		data.NewField("text", nil, texts),
+		data.NewField("tags", nil, tags),
+		data.NewField("Object id", nil, objectIds),
+		data.NewField("Reason", nil, reasons),
+		data.NewField("Comment", nil, comments),
+		data.NewField("User", nil, users),
+	)
+
+	return data.Frames{frame}
+}
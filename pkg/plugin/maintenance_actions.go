@@ -0,0 +1,127 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+// SCOM does not accept maintenance windows shorter than five minutes.
+const minMaintenanceMinutes = 5
+
+// Reasons SCOM accepts for maintenance mode.
+var maintenanceReasons = map[string]bool{
+	"PlannedOther":                            true,
+	"UnplannedOther":                          true,
+	"PlannedHardwareMaintenance":              true,
+	"UnplannedHardwareMaintenance":            true,
+	"PlannedHardwareInstallation":             true,
+	"UnplannedHardwareInstallation":           true,
+	"PlannedOperatingSystemReconfiguration":   true,
+	"UnplannedOperatingSystemReconfiguration": true,
+	"PlannedApplicationMaintenance":           true,
+	"UnplannedApplicationMaintenance":         true,
+	"ApplicationInstallation":                 true,
+	"ApplicationUnresponsive":                 true,
+	"ApplicationUnstable":                     true,
+	"SecurityIssue":                           true,
+	"LossOfNetworkConnectivity":               true,
+}
+
+// maintenanceActionRequest is the body posted to the maintenance mode routes. Object ids may be
+// objects or groups.
+type maintenanceActionRequest struct {
+	ObjectIds       []string `json:"objectIds"`
+	DurationMinutes int      `json:"durationMinutes"`
+	Reason          string   `json:"reason"`
+	Comment         string   `json:"comment"`
+	// Recursive includes contained objects, such as group members. Defaults to true.
+	Recursive *bool `json:"recursive"`
+}
+
+// maintenanceActions send a validated maintenance mode request to SCOM.
+var maintenanceActions = map[string]func(ctx context.Context, client *ScomClient, m models.MaintenanceMode) error{
+	"startMaintenance": func(ctx context.Context, client *ScomClient, m models.MaintenanceMode) error {
+		return client.StartMaintenanceMode(ctx, m)
+	},
+	"updateMaintenance": func(ctx context.Context, client *ScomClient, m models.MaintenanceMode) error {
+		return client.UpdateMaintenanceMode(ctx, m)
+	},
+	"stopMaintenance": func(ctx context.Context, client *ScomClient, m models.MaintenanceMode) error {
+		return client.StopMaintenanceMode(ctx, m)
+	},
+}
+
+// handleMaintenanceAction validates the request of a maintenance mode route and applies it, with
+// the acting Grafana user recorded in the comment.
+func (d *ScomDatasource) handleMaintenanceAction(ctx context.Context, req *backend.CallResourceRequest) (interface{}, error) {
+	if err := authorizeAction(req, "change maintenance mode"); err != nil {
+		return nil, err
+	}
+
+	var r maintenanceActionRequest
+	if err := json.Unmarshal(req.Body, &r); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	user := grafanaUser(req.PluginContext)
+
+	m, err := maintenanceMode(r, req.Path, user, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	backend.Logger.Info("Changing maintenance mode", "action", req.Path, "user", user, "objectIds", m.ObjectIds)
+
+	if err := maintenanceActions[req.Path](ctx, d.client, m); err != nil {
+		backend.Logger.Warn("Failed to change maintenance mode", "action", req.Path, "user", user, "objectIds", m.ObjectIds, "error", err)
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// maintenanceMode validates a maintenance mode request and converts it to the request sent to
+// SCOM. Stopping only needs the objects; starting and updating need a duration and a reason.
+func maintenanceMode(r maintenanceActionRequest, action, user string, now time.Time) (models.MaintenanceMode, error) {
+	ids, err := actionIds(r.ObjectIds, "objectIds", "object id")
+	if err != nil {
+		return models.MaintenanceMode{}, err
+	}
+
+	m := models.MaintenanceMode{
+		ObjectIds: ids,
+		Recursive: r.Recursive == nil || *r.Recursive,
+		Comments:  maintenanceComment(r.Comment, user),
+	}
+
+	if action == "stopMaintenance" {
+		return m, nil
+	}
+
+	if r.DurationMinutes < minMaintenanceMinutes {
+		return models.MaintenanceMode{}, fmt.Errorf("duration must be at least %d minutes", minMaintenanceMinutes)
+	}
+
+	if !maintenanceReasons[r.Reason] {
+		return models.MaintenanceMode{}, fmt.Errorf("unknown maintenance reason: %q", r.Reason)
+	}
+
+	m.EndTime = now.Add(time.Duration(r.DurationMinutes) * time.Minute).UTC().Format(time.RFC3339)
+	m.Reason = r.Reason
+
+	return m, nil
+}
+
+// maintenanceComment prefixes a comment with the Grafana user it was entered by.
+func maintenanceComment(comment, user string) string {
+	if comment == "" {
+		return "Grafana user " + user
+	}
+
+	return "Grafana user " + user + ": " + comment
+}
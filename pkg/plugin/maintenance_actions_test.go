@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	id := "6f7e8b3c-1d2a-4b5c-9e8f-0a1b2c3d4e5f"
+
+	m, err := maintenanceMode(maintenanceActionRequest{
+		ObjectIds:       []string{id},
+		DurationMinutes: 90,
+		Reason:          "PlannedOther",
+		Comment:         "Patching",
+	}, "startMaintenance", "operator", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.EndTime != "2024-01-10T13:30:00Z" || !m.Recursive || m.Comments != "Grafana user operator: Patching" {
+		t.Errorf("unexpected maintenance mode %+v", m)
+	}
+
+	for name, r := range map[string]maintenanceActionRequest{
+		"no objects":     {DurationMinutes: 60, Reason: "PlannedOther"},
+		"short duration": {ObjectIds: []string{id}, DurationMinutes: 1, Reason: "PlannedOther"},
+		"unknown reason": {ObjectIds: []string{id}, DurationMinutes: 60, Reason: "Lunch"},
+	} {
+		if _, err := maintenanceMode(r, "startMaintenance", "operator", now); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// Stopping needs neither duration nor reason.
+	if _, err := maintenanceMode(maintenanceActionRequest{ObjectIds: []string{id}}, "stopMaintenance", "operator", now); err != nil {
+		t.Errorf("unexpected error stopping maintenance: %v", err)
+	}
+}
+
+func TestStopMaintenanceRoute(t *testing.T) {
+	var stopped models.MaintenanceMode
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/OperationsManager/data/maintenanceMode/stop" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&stopped); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	ds := &ScomDatasource{client: client}
+	req := &backend.CallResourceRequest{
+		PluginContext: backend.PluginContext{User: &backend.User{Login: "operator", Role: "Admin"}},
+		Path:          "stopMaintenance",
+		Method:        http.MethodPost,
+		Body:          []byte(`{"objectIds": ["6f7e8b3c-1d2a-4b5c-9e8f-0a1b2c3d4e5f"], "recursive": false, "comment": "Done early"}`),
+	}
+
+	if response := callResource(t, ds, req); response.Status != http.StatusBadRequest {
+		t.Errorf("expected maintenance actions to need the server extension, got %d", response.Status)
+	}
+
+	client.settings.ServerExtension = true
+	response := callResource(t, ds, req)
+	if response.Status != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", response.Status, response.Body)
+	}
+
+	if stopped.Recursive || stopped.Comments != "Grafana user operator: Done early" {
+		t.Errorf("unexpected request %+v", stopped)
+	}
+}
+
+func TestMaintenanceRoutesRequireEditor(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+
+	ds := &ScomDatasource{client: client}
+	body := []byte(`{"objectIds": ["6f7e8b3c-1d2a-4b5c-9e8f-0a1b2c3d4e5f"], "durationMinutes": 30, "reason": "PlannedOther"}`)
+
+	response := callResource(t, ds, &backend.CallResourceRequest{
+		PluginContext: backend.PluginContext{User: &backend.User{Login: "viewer", Role: "Viewer"}},
+		Path:          "startMaintenance",
+		Method:        http.MethodPost,
+		Body:          body,
+	})
+	if response.Status != http.StatusForbidden {
+		t.Errorf("expected a viewer to be forbidden, got %d", response.Status)
+	}
+
+	response = callResource(t, ds, &backend.CallResourceRequest{
+		PluginContext: backend.PluginContext{User: &backend.User{Login: "operator", Role: "Editor"}},
+		Path:          "startMaintenance",
+		Method:        http.MethodPut,
+		Body:          body,
+	})
+	if response.Status != http.StatusMethodNotAllowed {
+		t.Errorf("expected PUT to be rejected as not allowed, got %d", response.Status)
+	}
+}
@@ -0,0 +1,44 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+func TestBuildMaintenanceFrame(t *testing.T) {
+	web := models.MonitoringObject{ID: "1", DisplayName: "web01"}
+	windows := []models.MaintenanceWindow{
+		{Object: web, StartTime: "2024-01-10T20:00:00Z", ScheduledEndTime: "2024-01-11T02:00:00Z", Reason: "PlannedOther"},
+		{Object: web, StartTime: "2024-01-10T02:00:00Z", EndTime: "2024-01-10T03:00:00Z", ScheduledEndTime: "2024-01-10T04:00:00Z", Reason: "SecurityIssue", Comments: "Patching"},
+		{Object: web, StartTime: "2024-01-01T02:00:00Z", EndTime: "2024-01-01T03:00:00Z"},
+	}
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC),
+	}
+
+	ds := ScomDatasource{}
+	frame := ds.buildMaintenanceFrame(windows, timeRange)[0]
+
+	if frame.Rows() != 2 {
+		t.Fatalf("expected 2 regions, got %d", frame.Rows())
+	}
+
+	// Ordered by start, ended windows end at their end time and running ones at the scheduled end.
+	if end := frame.Fields[1].At(0).(time.Time); !end.Equal(time.Date(2024, 1, 10, 3, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected end of first region %v", end)
+	}
+	if end := frame.Fields[1].At(1).(time.Time); !end.Equal(time.Date(2024, 1, 11, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected end of second region %v", end)
+	}
+
+	if text := frame.Fields[3].At(0); text != "Reason: SecurityIssue\nPatching" {
+		t.Errorf("unexpected text %q", text)
+	}
+	if tags := frame.Fields[4].At(0); tags != "maintenance,web01,SecurityIssue" {
+		t.Errorf("unexpected tags %q", tags)
+	}
+}
@@ -0,0 +1,364 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+// sample is a single performance value.
+type sample struct {
+	Time  time.Time
+	Value float64
+}
+
+// performanceSeries holds the samples of one counter of one object.
+type performanceSeries struct {
+	Name              string
+	ObjectId          string
+	ObjectDisplayName string
+	ObjectPath        string
+	ObjectFullName    string
+	ObjectScope       models.ObjectScope
+	Counter           models.PerformanceCounter
+	Samples           []sample
+	// Legend holds the statistics SCOM computed for the requested duration, if it returned any.
+	Legend *seriesStats
+}
+
+// seriesStats summarizes the samples of a series.
+type seriesStats struct {
+	Average float64
+	Minimum float64
+	Maximum float64
+	Last    float64
+}
+
+// toPerformanceSeries splits the performance responses into one series per object and counter,
+// with samples parsed and sorted by time.
+func toPerformanceSeries(performanceData []models.PerformanceResponse, counters []models.PerformanceCounter) []performanceSeries {
+	var result []performanceSeries
+
+	for _, entry := range performanceData {
+		entryCounters := datasetCounters(entry, counters)
+
+		legends := make(map[string]models.LegendRow, len(entry.Legends.Rows))
+		for _, row := range entry.Legends.Rows {
+			legends[row.ID] = row
+		}
+
+		for i, dataset := range entry.Datasets {
+			counter := entryCounters[i]
+
+			var legend *seriesStats
+			if row, ok := legends[dataset.ID]; ok {
+				legend = &seriesStats{
+					Average: row.AverageValue,
+					Minimum: row.MinimumValue,
+					Maximum: row.MaximumValue,
+					Last:    row.LastValue,
+				}
+			}
+
+			name := entry.ObjectDisplayName
+			if len(entry.Datasets) > 1 {
+				name = fmt.Sprintf("%s - %s", entry.ObjectDisplayName, counterDisplayName(counter))
+			}
+
+			result = append(result, performanceSeries{
+				Name:              name,
+				ObjectId:          entry.ObjectId,
+				ObjectDisplayName: entry.ObjectDisplayName,
+				ObjectPath:        entry.ObjectPath,
+				ObjectFullName:    entry.ObjectFullName,
+				ObjectScope:       entry.ObjectScope,
+				Counter:           counter,
+				Samples:           datasetSamples(dataset, entry.ObjectDisplayName),
+				Legend:            legend,
+			})
+		}
+	}
+
+	return result
+}
+
+// datasetSamples parses the samples of a dataset, skipping invalid ones, sorted by time.
+func datasetSamples(dataset models.Dataset, objectDisplayName string) []sample {
+	samples := make([]sample, 0, len(dataset.Data))
+
+	for timeStr, raw := range dataset.Data {
+		timeVal, err := time.Parse(time.RFC3339, timeStr)
+		if err != nil {
+			backend.Logger.Error("Error parsing time", "error", err)
+			continue
+		}
+
+		value, ok := raw.(float64)
+		if !ok {
+			backend.Logger.Error("Invalid value type", "time", timeStr, "entry", objectDisplayName)
+			continue
+		}
+
+		samples = append(samples, sample{Time: timeVal, Value: value})
+	}
+
+	// Sort timestamps to ensure order
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].Time.Before(samples[j].Time)
+	})
+
+	return samples
+}
+
+// datasetCounters returns the counter of every dataset in entry. Datasets are matched to the legend
+// rows SCOM returns with them; without a legend the requested counters are used in order.
+func datasetCounters(entry models.PerformanceResponse, counters []models.PerformanceCounter) []models.PerformanceCounter {
+	legends := make(map[string]models.PerformanceCounter, len(entry.Legends.Rows))
+	for _, row := range entry.Legends.Rows {
+		legends[row.ID] = models.PerformanceCounter{
+			ObjectName:   row.PerformanceObject,
+			CounterName:  row.PerformanceCounter,
+			InstanceName: row.PerformanceInstance,
+		}
+	}
+
+	result := make([]models.PerformanceCounter, len(entry.Datasets))
+	for i, dataset := range entry.Datasets {
+		if counter, ok := legends[dataset.ID]; ok {
+			result[i] = counter
+		} else if len(counters) == len(entry.Datasets) {
+			result[i] = counters[i]
+		} else if len(counters) == 1 {
+			result[i] = counters[0]
+		}
+	}
+
+	return result
+}
+
+// counterDisplayName formats a counter as "Counter name (instance)", omitting an empty instance.
+func counterDisplayName(counter models.PerformanceCounter) string {
+	if counter.InstanceName == "" {
+		return counter.CounterName
+	}
+
+	return fmt.Sprintf("%s (%s)", counter.CounterName, counter.InstanceName)
+}
+
+func isValidAggregation(aggregation string) bool {
+	switch aggregation {
+	case "", models.AggregationNone, models.AggregationAvg, models.AggregationMin, models.AggregationMax,
+		models.AggregationLast, models.AggregationSum, models.AggregationCount:
+		return true
+	}
+
+	return false
+}
+
+// resampleInterval returns the bucket width for a query: the panel interval, widened so the time
+// range does not produce more than MaxDataPoints buckets.
+func resampleInterval(query backend.DataQuery) time.Duration {
+	interval := query.Interval
+	if query.MaxDataPoints > 0 {
+		if perPoint := query.TimeRange.Duration() / time.Duration(query.MaxDataPoints); perPoint > interval {
+			interval = perPoint
+		}
+	}
+
+	return interval
+}
+
+// resampleSeries aggregates the samples of every series into buckets of interval. Without an
+// explicit aggregation only series with more than maxDataPoints samples are averaged.
+func resampleSeries(series []performanceSeries, interval time.Duration, aggregation string, maxDataPoints int64) []performanceSeries {
+	if interval <= 0 || aggregation == models.AggregationNone {
+		return series
+	}
+
+	for i := range series {
+		if aggregation == "" {
+			if maxDataPoints <= 0 || int64(len(series[i].Samples)) <= maxDataPoints {
+				continue
+			}
+			series[i].Samples = resample(series[i].Samples, interval, models.AggregationAvg)
+			continue
+		}
+
+		series[i].Samples = resample(series[i].Samples, interval, aggregation)
+	}
+
+	return series
+}
+
+// resample aggregates time-sorted samples into buckets of interval aligned to the Unix epoch.
+// Every bucket is stamped with its start time.
+func resample(samples []sample, interval time.Duration, aggregation string) []sample {
+	var result []sample
+
+	for start := 0; start < len(samples); {
+		bucket := bucketStart(samples[start].Time, interval)
+
+		end := start + 1
+		for end < len(samples) && bucketStart(samples[end].Time, interval).Equal(bucket) {
+			end++
+		}
+
+		result = append(result, sample{Time: bucket, Value: aggregate(samples[start:end], aggregation)})
+		start = end
+	}
+
+	return result
+}
+
+// bucketStart returns the start of the bucket of interval t falls in. Buckets are counted from the
+// Unix epoch rather than the zero time time.Truncate uses, so they line up with Grafana intervals.
+func bucketStart(t time.Time, interval time.Duration) time.Time {
+	offset := time.Duration(t.UnixNano() % int64(interval))
+	if offset < 0 {
+		offset += interval
+	}
+
+	return t.Add(-offset)
+}
+
+// aggregate reduces a non-empty set of samples to a single value.
+func aggregate(samples []sample, aggregation string) float64 {
+	switch aggregation {
+	case models.AggregationCount:
+		return float64(len(samples))
+	case models.AggregationLast:
+		return samples[len(samples)-1].Value
+	}
+
+	result := samples[0].Value
+	for _, s := range samples[1:] {
+		switch aggregation {
+		case models.AggregationMin:
+			if s.Value < result {
+				result = s.Value
+			}
+		case models.AggregationMax:
+			if s.Value > result {
+				result = s.Value
+			}
+		default:
+			result += s.Value
+		}
+	}
+
+	if aggregation == models.AggregationAvg {
+		result /= float64(len(samples))
+	}
+
+	return result
+}
+
+// computeStats summarizes samples. ok is false if there are none.
+func computeStats(samples []sample) (stats seriesStats, ok bool) {
+	if len(samples) == 0 {
+		return stats, false
+	}
+
+	stats = seriesStats{
+		Average: aggregate(samples, models.AggregationAvg),
+		Minimum: aggregate(samples, models.AggregationMin),
+		Maximum: aggregate(samples, models.AggregationMax),
+		Last:    aggregate(samples, models.AggregationLast),
+	}
+
+	return stats, true
+}
+
+// summarizeSeries returns the statistics of a series over the query range. SCOM computes legend
+// statistics for the requested duration, which only matches the range when it ends now; otherwise
+// they are computed from the trimmed samples.
+func summarizeSeries(series performanceSeries, rangeEndsNow bool) (seriesStats, bool) {
+	if rangeEndsNow && series.Legend != nil {
+		return *series.Legend, true
+	}
+
+	return computeStats(series.Samples)
+}
+
+// rangeEndsNow reports whether a time range reaches the present, as relative dashboard ranges do.
+func rangeEndsNow(timeRange backend.TimeRange) bool {
+	return time.Since(timeRange.To) < time.Minute
+}
+
+// validateRanking checks the ranking options of a performance query.
+func validateRanking(ranking *models.PerformanceRanking) error {
+	if ranking == nil {
+		return nil
+	}
+
+	if ranking.Order != models.RankingTop && ranking.Order != models.RankingBottom {
+		return fmt.Errorf("unknown ranking order: %s", ranking.Order)
+	}
+
+	switch ranking.By {
+	case models.AggregationAvg, models.AggregationMax, models.AggregationLast:
+	default:
+		return fmt.Errorf("unknown ranking statistic: %s", ranking.By)
+	}
+
+	if ranking.Limit < 1 {
+		return fmt.Errorf("ranking limit must be at least 1")
+	}
+
+	return nil
+}
+
+// rankSeries keeps the ranking.Limit highest or lowest series by the ranking statistic over the
+// query range, ordered by rank. Series without samples in the range are never ranked.
+func rankSeries(series []performanceSeries, ranking *models.PerformanceRanking, rangeEndsNow bool) []performanceSeries {
+	if ranking == nil {
+		return series
+	}
+
+	type rankedSeries struct {
+		series performanceSeries
+		value  float64
+	}
+
+	ranked := make([]rankedSeries, 0, len(series))
+	for _, entry := range series {
+		stats, ok := summarizeSeries(entry, rangeEndsNow)
+		if !ok {
+			continue
+		}
+
+		value := stats.Average
+		switch ranking.By {
+		case models.AggregationMax:
+			value = stats.Maximum
+		case models.AggregationLast:
+			value = stats.Last
+		}
+
+		ranked = append(ranked, rankedSeries{series: entry, value: value})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].value == ranked[j].value {
+			return ranked[i].series.Name < ranked[j].series.Name
+		}
+		if ranking.Order == models.RankingBottom {
+			return ranked[i].value < ranked[j].value
+		}
+		return ranked[i].value > ranked[j].value
+	})
+
+	if len(ranked) > ranking.Limit {
+		ranked = ranked[:ranking.Limit]
+	}
+
+	result := make([]performanceSeries, len(ranked))
+	for i, entry := range ranked {
+		result[i] = entry.series
+	}
+
+	return result
+}
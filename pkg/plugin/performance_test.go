@@ -0,0 +1,139 @@
+package plugin
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+func TestResample(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	samples := []sample{
+		{Time: base, Value: 1},
+		{Time: base.Add(2 * time.Minute), Value: 5},
+		{Time: base.Add(4 * time.Minute), Value: 3},
+		{Time: base.Add(5 * time.Minute), Value: 10},
+		{Time: base.Add(9 * time.Minute), Value: 2},
+	}
+
+	tests := []struct {
+		aggregation string
+		want        []float64
+	}{
+		{models.AggregationAvg, []float64{3, 6}},
+		{models.AggregationMin, []float64{1, 2}},
+		{models.AggregationMax, []float64{5, 10}},
+		{models.AggregationLast, []float64{3, 2}},
+		{models.AggregationSum, []float64{9, 12}},
+		{models.AggregationCount, []float64{3, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.aggregation, func(t *testing.T) {
+			result := resample(samples, 5*time.Minute, tt.aggregation)
+			if len(result) != len(tt.want) {
+				t.Fatalf("expected %d buckets, got %d", len(tt.want), len(result))
+			}
+			for i, s := range result {
+				if s.Value != tt.want[i] {
+					t.Errorf("bucket %d: got %v, want %v", i, s.Value, tt.want[i])
+				}
+				if !s.Time.Equal(base.Add(time.Duration(i) * 5 * time.Minute)) {
+					t.Errorf("bucket %d stamped %s", i, s.Time)
+				}
+			}
+		})
+	}
+}
+
+func TestBucketStartAlignsToUnixEpoch(t *testing.T) {
+	interval := 7 * time.Minute
+	at := time.Date(2024, 1, 1, 12, 34, 56, 0, time.UTC)
+
+	start := bucketStart(at, interval)
+	if start.Unix()%int64(interval.Seconds()) != 0 {
+		t.Errorf("bucket start %s is not aligned to the Unix epoch", start)
+	}
+	if start.After(at) || at.Sub(start) >= interval {
+		t.Errorf("bucket start %s does not contain %s", start, at)
+	}
+}
+
+func TestResampleSeriesOnlyWhenNeeded(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	series := []performanceSeries{{Samples: []sample{
+		{Time: base, Value: 1},
+		{Time: base.Add(time.Minute), Value: 3},
+	}}}
+
+	series = resampleSeries(series, 5*time.Minute, "", 10)
+	if len(series[0].Samples) != 2 {
+		t.Fatalf("series below max data points should be left untouched")
+	}
+
+	series = resampleSeries(series, 5*time.Minute, "", 1)
+	if len(series[0].Samples) != 1 || series[0].Samples[0].Value != 2 {
+		t.Fatalf("series above max data points should be averaged, got %+v", series[0].Samples)
+	}
+}
+
+func TestSummarizeSeries(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	series := performanceSeries{
+		Samples: []sample{{Time: base, Value: 4}, {Time: base.Add(time.Minute), Value: 8}, {Time: base.Add(2 * time.Minute), Value: 3}},
+		Legend:  &seriesStats{Average: 1, Minimum: 1, Maximum: 1, Last: 1},
+	}
+
+	if stats, _ := summarizeSeries(series, true); stats != *series.Legend {
+		t.Errorf("expected legend statistics for a range ending now, got %+v", stats)
+	}
+
+	want := seriesStats{Average: 5, Minimum: 3, Maximum: 8, Last: 3}
+	if stats, ok := summarizeSeries(series, false); !ok || stats != want {
+		t.Errorf("got %+v, want %+v", stats, want)
+	}
+
+	if _, ok := summarizeSeries(performanceSeries{}, false); ok {
+		t.Error("expected no statistics for an empty series")
+	}
+}
+
+func TestRankSeries(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newSeries := func(name string, values ...float64) performanceSeries {
+		s := performanceSeries{Name: name}
+		for i, v := range values {
+			s.Samples = append(s.Samples, sample{Time: base.Add(time.Duration(i) * time.Minute), Value: v})
+		}
+		return s
+	}
+
+	series := []performanceSeries{
+		newSeries("disk-a", 10, 90),
+		newSeries("disk-b", 60, 60),
+		newSeries("disk-c", 20, 30),
+		newSeries("disk-d"),
+	}
+
+	tests := []struct {
+		ranking models.PerformanceRanking
+		want    []string
+	}{
+		{models.PerformanceRanking{Order: models.RankingTop, Limit: 2, By: models.AggregationAvg}, []string{"disk-b", "disk-a"}},
+		{models.PerformanceRanking{Order: models.RankingTop, Limit: 1, By: models.AggregationMax}, []string{"disk-a"}},
+		{models.PerformanceRanking{Order: models.RankingBottom, Limit: 5, By: models.AggregationLast}, []string{"disk-c", "disk-b", "disk-a"}},
+	}
+
+	for _, tt := range tests {
+		result := rankSeries(series, &tt.ranking, false)
+		var names []string
+		for _, s := range result {
+			names = append(names, s.Name)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("%+v: got %v, want %v", tt.ranking, names, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package plugin
+
+import (
+	"context"
+	"sync"
+)
+
+// WorkerPool bounds the number of concurrent SCOM requests issued by fan-out calls. A single pool
+// is shared by all fan-out paths of a client, so the limit holds across concurrent queries.
+type WorkerPool struct {
+	slots chan struct{}
+}
+
+// NewWorkerPool creates a pool allowing at most limit concurrent tasks.
+func NewWorkerPool(limit int) *WorkerPool {
+	if limit < 1 {
+		limit = 1
+	}
+
+	return &WorkerPool{slots: make(chan struct{}, limit)}
+}
+
+// fanOut calls fn for every item on the pool and returns the results in the order of items.
+// The first error cancels the remaining work and is returned. fn must not submit work to the
+// same pool, otherwise the pool can run out of slots while waiting on itself.
+func fanOut[T, R any](ctx context.Context, pool *WorkerPool, items []T, fn func(context.Context, T) (R, error)) ([]R, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		results  = make([]R, len(items))
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+
+loop:
+	for i, item := range items {
+		select {
+		case pool.slots <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+
+		wg.Add(1)
+		go func(i int, item T) {
+			defer func() {
+				<-pool.slots
+				wg.Done()
+			}()
+
+			result, err := fn(ctx, item)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+
+			results[i] = result
+		}(i, item)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,65 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFanOutOrderAndLimit(t *testing.T) {
+	pool := NewWorkerPool(3)
+
+	items := make([]int, 50)
+	for i := range items {
+		items[i] = i
+	}
+
+	var running, peak int32
+	results, err := fanOut(context.Background(), pool, items, func(_ context.Context, item int) (int, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		// Finish out of order to make sure results are still placed by index.
+		time.Sleep(time.Duration(50-item) * 100 * time.Microsecond)
+		atomic.AddInt32(&running, -1)
+		return item * 2, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if peak > 3 {
+		t.Errorf("expected at most 3 concurrent tasks, got %d", peak)
+	}
+	for i, result := range results {
+		if result != i*2 {
+			t.Fatalf("result %d out of order: %d", i, result)
+		}
+	}
+}
+
+func TestFanOutStopsOnError(t *testing.T) {
+	pool := NewWorkerPool(2)
+	failure := errors.New("boom")
+
+	var calls int32
+	_, err := fanOut(context.Background(), pool, make([]int, 100), func(ctx context.Context, _ int) (int, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return 0, failure
+		}
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected first error, got %v", err)
+	}
+	if calls >= 100 {
+		t.Errorf("expected remaining work to be cancelled, got %d calls", calls)
+	}
+}
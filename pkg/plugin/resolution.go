@@ -0,0 +1,150 @@
+package plugin
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+	"golang.org/x/sync/singleflight"
+)
+
+// Names of the resolution states every management group defines, used until the definitions of
+// the management group could be loaded.
+var defaultResolutionStateNames = map[int]string{
+	0:   "New",
+	247: "Awaiting Evidence",
+	248: "Assigned to Engineering",
+	249: "Acknowledged",
+	250: "Scheduled",
+	254: "Resolved",
+	255: "Closed",
+}
+
+const (
+	// Delay before loading the resolution states again after a failed load.
+	resolutionStateRetryDelay = time.Minute
+	// Limit on loading the resolution states, shared by every query waiting on the load.
+	resolutionStateLoadTimeout = 30 * time.Second
+)
+
+// resolutionStateCache holds the resolution state names of a management group. The definitions
+// are loaded once by a single load shared between queries; failed loads are retried after
+// resolutionStateRetryDelay.
+type resolutionStateCache struct {
+	mu       sync.Mutex
+	names    map[int]string
+	failedAt time.Time
+	load     singleflight.Group
+}
+
+// resolutionStateNames returns the names of the resolution states by number, loading them on
+// first use. The default names are returned while loading fails, and to queries whose context is
+// done before an in-flight load completes.
+func (d *ScomDatasource) resolutionStateNames(ctx context.Context) map[int]string {
+	cache := &d.resolutionStates
+
+	cache.mu.Lock()
+	if cache.names != nil {
+		names := cache.names
+		cache.mu.Unlock()
+		return names
+	}
+
+	if !cache.failedAt.IsZero() && time.Since(cache.failedAt) < resolutionStateRetryDelay {
+		cache.mu.Unlock()
+		return defaultResolutionStateNames
+	}
+
+	cache.mu.Unlock()
+
+	// Load without holding the lock, and without the cancellation of the initiating query as the
+	// load is shared.
+	result := cache.load.DoChan("", func() (interface{}, error) {
+		// A load that completed after the check above leaves nothing to do.
+		cache.mu.Lock()
+		names := cache.names
+		cache.mu.Unlock()
+		if names != nil {
+			return names, nil
+		}
+
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), resolutionStateLoadTimeout)
+		defer cancel()
+
+		states, err := d.client.GetResolutionStates(loadCtx)
+		if err != nil {
+			backend.Logger.Warn("Failed to load resolution states, using defaults", "error", err, "retryAfter", resolutionStateRetryDelay)
+
+			cache.mu.Lock()
+			cache.failedAt = time.Now()
+			cache.mu.Unlock()
+
+			return defaultResolutionStateNames, nil
+		}
+
+		names = make(map[int]string, len(defaultResolutionStateNames)+len(states))
+		for state, name := range defaultResolutionStateNames {
+			names[state] = name
+		}
+		for _, state := range states {
+			names[state.ResolutionState] = state.Name
+		}
+
+		cache.mu.Lock()
+		cache.names = names
+		cache.mu.Unlock()
+
+		return names, nil
+	})
+
+	select {
+	case r := <-result:
+		return r.Val.(map[int]string)
+	case <-ctx.Done():
+		return defaultResolutionStateNames
+	}
+}
+
+// resolutionStatesLoaded reports whether the resolution states of the management group were loaded,
+// rather than only the defaults being known.
+func (d *ScomDatasource) resolutionStatesLoaded() bool {
+	d.resolutionStates.mu.Lock()
+	defer d.resolutionStates.mu.Unlock()
+
+	return d.resolutionStates.names != nil
+}
+
+// resolutionStateFields returns the numeric resolution state and its name for every alert. Alerts
+// without a resolution state hold null in both fields, unknown states are named by their number.
+func resolutionStateFields(alerts models.ScomAlert, names map[int]string) (*data.Field, *data.Field) {
+	states := make([]*int64, len(alerts.Rows))
+	stateNames := make([]*string, len(alerts.Rows))
+
+	for i, alert := range alerts.Rows {
+		state, err := strconv.Atoi(string(alert.ResolutionState))
+		if err != nil {
+			continue
+		}
+
+		number := int64(state)
+		states[i] = &number
+
+		name := resolutionStateName(state, names)
+		stateNames[i] = &name
+	}
+
+	return data.NewField("Resolution state", nil, states), data.NewField("Resolution state name", nil, stateNames)
+}
+
+// resolutionStateName returns the name of a resolution state, or its number when it is unknown.
+func resolutionStateName(state int, names map[int]string) string {
+	if name, ok := names[state]; ok {
+		return name
+	}
+
+	return strconv.Itoa(state)
+}
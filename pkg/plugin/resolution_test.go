@@ -0,0 +1,108 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+func TestResolutionStateNamesCached(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/OperationsManager/data/alertResolutionStates" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		requests.Add(1)
+		_, _ = w.Write([]byte(`[{"id": "a", "name": "New", "resolutionState": 0}, {"id": "b", "name": "Waiting for vendor", "resolutionState": 10}]`))
+	}))
+
+	ds := &ScomDatasource{client: client}
+	for i := 0; i < 2; i++ {
+		names := ds.resolutionStateNames(context.Background())
+		if names[10] != "Waiting for vendor" || names[255] != "Closed" {
+			t.Errorf("unexpected names %v", names)
+		}
+	}
+
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected resolution states to be loaded once, got %d requests", n)
+	}
+}
+
+func TestResolutionStateNamesFailureCached(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	ds := &ScomDatasource{client: client}
+	for i := 0; i < 2; i++ {
+		if names := ds.resolutionStateNames(context.Background()); names[255] != "Closed" {
+			t.Errorf("expected default names, got %v", names)
+		}
+	}
+
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected a failed load to be retried after a delay, got %d requests", n)
+	}
+}
+
+func TestResolutionStateNamesLoadShared(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`[{"id": "b", "name": "Waiting for vendor", "resolutionState": 10}]`))
+	}))
+
+	ds := &ScomDatasource{client: client}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if names := ds.resolutionStateNames(context.Background()); names[10] != "Waiting for vendor" {
+				t.Errorf("unexpected names %v", names)
+			}
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected a single shared load, got %d requests", n)
+	}
+}
+
+func TestResolutionStateFields(t *testing.T) {
+	alerts := models.ScomAlert{Rows: []models.ScomAlertRow{
+		{ResolutionState: "249"},
+		{ResolutionState: "12"},
+		{},
+	}}
+
+	states, names := resolutionStateFields(alerts, defaultResolutionStateNames)
+
+	if state, _ := states.ConcreteAt(0); state != int64(249) {
+		t.Errorf("unexpected state %v", state)
+	}
+	if name, _ := names.ConcreteAt(0); name != "Acknowledged" {
+		t.Errorf("unexpected name %v", name)
+	}
+	if name, _ := names.ConcreteAt(1); name != "12" {
+		t.Errorf("expected unknown state to be named by its number, got %v", name)
+	}
+	if _, ok := states.ConcreteAt(2); ok {
+		t.Errorf("expected null state for an alert without resolution state")
+	}
+}
@@ -0,0 +1,116 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+// validateRollup checks the rollup options of a performance query.
+func validateRollup(rollup *models.PerformanceRollup, groups []models.ScomGroup) error {
+	if rollup == nil {
+		return nil
+	}
+
+	if len(groups) == 0 {
+		return fmt.Errorf("rollup requires at least one group")
+	}
+
+	switch rollup.Aggregation {
+	case models.AggregationAvg, models.AggregationSum, models.AggregationMin, models.AggregationMax:
+	case models.AggregationPercentile:
+		if rollup.Percentile <= 0 || rollup.Percentile > 100 {
+			return fmt.Errorf("rollup percentile must be between 0 and 100")
+		}
+	default:
+		return fmt.Errorf("unknown rollup aggregation: %s", rollup.Aggregation)
+	}
+
+	return nil
+}
+
+// rollupSeries aggregates member series into a single series per group and counter. Members are
+// first resampled to interval with aggregation so their samples line up, then combined per bucket
+// with the rollup aggregation. Members of several groups contribute to each of them.
+func rollupSeries(series []performanceSeries, rollup *models.PerformanceRollup, interval time.Duration, aggregation string) []performanceSeries {
+	if aggregation == "" || aggregation == models.AggregationNone {
+		aggregation = models.AggregationAvg
+	}
+
+	type rollupKey struct {
+		group   string
+		counter models.PerformanceCounter
+	}
+
+	var keys []rollupKey
+	buckets := map[rollupKey]map[time.Time][]float64{}
+
+	for _, entry := range series {
+		samples := entry.Samples
+		if interval > 0 {
+			samples = resample(samples, interval, aggregation)
+		}
+
+		for _, group := range entry.ObjectScope.Groups {
+			key := rollupKey{group: group, counter: entry.Counter}
+			if _, exists := buckets[key]; !exists {
+				keys = append(keys, key)
+				buckets[key] = map[time.Time][]float64{}
+			}
+
+			for _, s := range samples {
+				buckets[key][s.Time] = append(buckets[key][s.Time], s.Value)
+			}
+		}
+	}
+
+	result := make([]performanceSeries, 0, len(keys))
+	for _, key := range keys {
+		samples := make([]sample, 0, len(buckets[key]))
+		for t, values := range buckets[key] {
+			samples = append(samples, sample{Time: t, Value: rollupValue(values, rollup)})
+		}
+		sort.Slice(samples, func(i, j int) bool {
+			return samples[i].Time.Before(samples[j].Time)
+		})
+
+		result = append(result, performanceSeries{
+			Name:              fmt.Sprintf("%s - %s", key.group, counterDisplayName(key.counter)),
+			ObjectDisplayName: key.group,
+			ObjectScope:       models.ObjectScope{Groups: []string{key.group}},
+			Counter:           key.counter,
+			Samples:           samples,
+		})
+	}
+
+	return result
+}
+
+// rollupValue combines the values of all members at a single timestamp.
+func rollupValue(values []float64, rollup *models.PerformanceRollup) float64 {
+	if rollup.Aggregation == models.AggregationPercentile {
+		return percentile(values, rollup.Percentile)
+	}
+
+	samples := make([]sample, len(values))
+	for i, v := range values {
+		samples[i] = sample{Value: v}
+	}
+
+	return aggregate(samples, rollup.Aggregation)
+}
+
+// percentile returns the p-th percentile of values using linear interpolation between closest ranks.
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,56 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+func TestRollupSeries(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	counter := models.PerformanceCounter{CounterName: "Available MBytes"}
+	member := func(groups []string, values ...float64) performanceSeries {
+		s := performanceSeries{Counter: counter, ObjectScope: models.ObjectScope{Groups: groups}}
+		for i, v := range values {
+			// Members report a few seconds apart and must still share buckets.
+			s.Samples = append(s.Samples, sample{Time: base.Add(time.Duration(i)*time.Minute + time.Duration(len(values))*time.Second), Value: v})
+		}
+		return s
+	}
+
+	series := []performanceSeries{
+		member([]string{"Web Farm"}, 100, 200),
+		member([]string{"Web Farm", "DR"}, 300, 400),
+		member([]string{"DR"}, 50),
+	}
+
+	result := rollupSeries(series, &models.PerformanceRollup{Aggregation: models.AggregationSum}, time.Minute, "")
+	if len(result) != 2 {
+		t.Fatalf("expected a series per group, got %d", len(result))
+	}
+
+	web := result[0]
+	if web.Name != "Web Farm - Available MBytes" || len(web.Samples) != 2 {
+		t.Fatalf("unexpected series %q with %d samples", web.Name, len(web.Samples))
+	}
+	if web.Samples[0].Value != 400 || web.Samples[1].Value != 600 {
+		t.Errorf("unexpected sums %v, %v", web.Samples[0].Value, web.Samples[1].Value)
+	}
+
+	dr := result[1]
+	if dr.Samples[0].Value != 350 {
+		t.Errorf("unexpected DR sum %v", dr.Samples[0].Value)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{40, 10, 30, 20}
+
+	if got := percentile(values, 50); got != 25 {
+		t.Errorf("p50: got %v", got)
+	}
+	if got := percentile(values, 100); got != 40 {
+		t.Errorf("p100: got %v", got)
+	}
+}
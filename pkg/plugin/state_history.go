@@ -0,0 +1,191 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+// Monitor rolling up the health of every monitor of an object into the object health state.
+const entityHealthMonitor = "System.Health.EntityState"
+
+// stateInterval is a period an object or monitor spent in a health state.
+type stateInterval struct {
+	State      string
+	Start, End time.Time
+}
+
+// stateSeries is the health state history of an object, or of a monitor of an object.
+type stateSeries struct {
+	Name      string
+	Object    models.MonitoringObject
+	MonitorID string
+	Intervals []stateInterval
+}
+
+func validateStateHistoryLevel(level string) error {
+	switch level {
+	case "", models.StateHistoryLevelObject, models.StateHistoryLevelMonitor:
+		return nil
+	}
+
+	return fmt.Errorf("unknown state history level: %s", level)
+}
+
+// stateHistory reconstructs the health state history of every object, or every monitor of every
+// object for the monitor level, over timeRange. Events must cover the time from the start of the
+// range until now: the state of a series is walked back from its current state, so series without
+// events spent the whole range in their current state.
+func stateHistory(healthStates []models.MonitoringDataResponse, objects []models.MonitoringObject, events []models.StateChangeEvent, level string, timeRange backend.TimeRange, now time.Time) []stateSeries {
+	monitorLevel := level == models.StateHistoryLevelMonitor
+
+	type seriesEvents struct {
+		series  stateSeries
+		current string
+		events  []models.StateChangeEvent
+	}
+
+	var (
+		order []string
+		index = map[string]*seriesEvents{}
+	)
+
+	for _, node := range monitorTree(healthStates, objects) {
+		isObject := node.ParentID == ""
+		if isObject == monitorLevel {
+			continue
+		}
+
+		key := node.Object.ID
+		name := node.Object.DisplayName
+		if monitorLevel {
+			key += "/" + node.MonitorID
+			name += " / " + node.MonitorDisplayName
+			if node.MonitorDisplayName == "" {
+				name += node.MonitorName
+			}
+		}
+
+		if _, exists := index[key]; exists {
+			continue
+		}
+
+		order = append(order, key)
+		index[key] = &seriesEvents{
+			series:  stateSeries{Name: name, Object: node.Object, MonitorID: node.MonitorID},
+			current: node.HealthState,
+		}
+	}
+
+	for _, event := range events {
+		key := event.Object.ID
+		if monitorLevel {
+			key += "/" + event.MonitorID
+		} else if event.MonitorName != entityHealthMonitor {
+			continue
+		}
+
+		if entry, ok := index[key]; ok {
+			entry.events = append(entry.events, event)
+		}
+	}
+
+	series := make([]stateSeries, 0, len(order))
+	for _, key := range order {
+		entry := index[key]
+		entry.series.Intervals = stateIntervals(entry.current, entry.events, timeRange, now)
+		series = append(series, entry.series)
+	}
+
+	return series
+}
+
+// stateIntervals returns the states of a series within timeRange, given its current state and
+// its state changes since the start of the range. Events without a valid time are ignored.
+func stateIntervals(current string, events []models.StateChangeEvent, timeRange backend.TimeRange, now time.Time) []stateInterval {
+	type change struct {
+		time     time.Time
+		oldState string
+		newState string
+	}
+
+	var changes []change
+	for _, event := range events {
+		t := parseScomTime(event.TimeGenerated)
+		if t == nil || t.Before(timeRange.From) {
+			continue
+		}
+		changes = append(changes, change{time: *t, oldState: event.OldHealthState, newState: event.NewHealthState})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].time.Before(changes[j].time)
+	})
+
+	state := current
+	if len(changes) > 0 {
+		state = changes[0].oldState
+	}
+
+	end := timeRange.To
+	if now.Before(end) {
+		end = now
+	}
+
+	var intervals []stateInterval
+	start := timeRange.From
+	for _, c := range changes {
+		if !c.time.Before(end) {
+			break
+		}
+
+		if c.time.After(start) && c.newState != state {
+			intervals = append(intervals, stateInterval{State: state, Start: start, End: c.time})
+			start = c.time
+		}
+		state = c.newState
+	}
+
+	if start.Before(end) {
+		intervals = append(intervals, stateInterval{State: state, Start: start, End: end})
+	}
+
+	return intervals
+}
+
+// buildStateHistoryFrames returns a frame per series with the time every state was entered and the
+// code of the state, as read by the state timeline panel.
+func (d *ScomDatasource) buildStateHistoryFrames(series []stateSeries) data.Frames {
+	frames := make(data.Frames, 0, len(series))
+
+	for _, entry := range series {
+		times := make([]time.Time, len(entry.Intervals))
+		states := make([]int64, len(entry.Intervals))
+		for i, interval := range entry.Intervals {
+			times[i] = interval.Start
+			states[i] = healthStateCode(interval.State)
+		}
+
+		labels := data.Labels{"object": entry.Object.DisplayName}
+		if entry.MonitorID != "" {
+			labels["monitor"] = entry.MonitorID
+		}
+
+		config := healthStateConfig()
+		config.DisplayNameFromDS = entry.Name
+
+		stateField := data.NewField("Health state", labels, states)
+		stateField.SetConfig(config)
+
+		frames = append(frames, data.NewFrame(entry.Name,
+			data.NewField("Time", nil, times),
+			stateField,
+		))
+	}
+
+	return frames
+}
@@ -0,0 +1,60 @@
+package plugin
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+func TestStateHistory(t *testing.T) {
+	web := models.MonitoringObject{ID: "web", DisplayName: "web01"}
+	sql := models.MonitoringObject{ID: "sql", DisplayName: "sql01"}
+	healthStates := []models.MonitoringDataResponse{
+		{ObjectID: "web", HealthState: "Success", ChildNodeDatas: []models.ChildNodeData{{ID: "cpu", MonitorID: "cpu", MonitorDisplayName: "CPU", HealthState: "Success"}}},
+		{ObjectID: "sql", HealthState: "Warning"},
+	}
+	events := []models.StateChangeEvent{
+		// Changes after the end of the range still walk the state back from the current one.
+		{Object: web, MonitorName: entityHealthMonitor, OldHealthState: "Error", NewHealthState: "Success", TimeGenerated: "2024-01-10T20:00:00Z"},
+		{Object: web, MonitorName: entityHealthMonitor, OldHealthState: "Success", NewHealthState: "Error", TimeGenerated: "2024-01-10T06:00:00Z"},
+		{Object: web, MonitorID: "cpu", MonitorName: "Cpu.Utilization", OldHealthState: "Success", NewHealthState: "Error", TimeGenerated: "2024-01-10T06:00:00Z"},
+	}
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC),
+	}
+	now := time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)
+
+	series := stateHistory(healthStates, []models.MonitoringObject{web, sql}, events, "", timeRange, now)
+
+	if len(series) != 2 {
+		t.Fatalf("expected a series per object, got %d", len(series))
+	}
+
+	want := []stateInterval{
+		{State: "Success", Start: timeRange.From, End: time.Date(2024, 1, 10, 6, 0, 0, 0, time.UTC)},
+		{State: "Error", Start: time.Date(2024, 1, 10, 6, 0, 0, 0, time.UTC), End: timeRange.To},
+	}
+	if !reflect.DeepEqual(series[0].Intervals, want) {
+		t.Errorf("got %+v, want %+v", series[0].Intervals, want)
+	}
+
+	// Without events the object spent the whole range in its current state.
+	if want := []stateInterval{{State: "Warning", Start: timeRange.From, End: timeRange.To}}; !reflect.DeepEqual(series[1].Intervals, want) {
+		t.Errorf("got %+v, want %+v", series[1].Intervals, want)
+	}
+
+	monitors := stateHistory(healthStates, []models.MonitoringObject{web, sql}, events, models.StateHistoryLevelMonitor, timeRange, now)
+	if len(monitors) != 1 || monitors[0].Name != "web01 / CPU" || len(monitors[0].Intervals) != 2 {
+		t.Errorf("unexpected monitor series %+v", monitors)
+	}
+
+	ds := ScomDatasource{}
+	frame := ds.buildStateHistoryFrames(series)[0]
+	if frame.Rows() != 2 || frame.Fields[1].At(1) != healthStateError {
+		t.Errorf("unexpected frame rows %d", frame.Rows())
+	}
+}
@@ -0,0 +1,193 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// Fraction of the session lifetime after which tokens are refreshed in the background.
+	tokenRefreshRatio = 0.8
+	// Delay before retrying a failed background refresh.
+	tokenRefreshRetryDelay = 30 * time.Second
+	// Limit on a single authentication call, so a hanging SCOM cannot block the requests waiting
+	// on a shared refresh.
+	authenticateTimeout = 30 * time.Second
+	// Sessions younger than this are not refreshed when SCOM answers 401: it just issued the session,
+	// so the request is unauthorized rather than the session expired, and logging in again would not help.
+	minExpiredSessionAge = 30 * time.Second
+)
+
+// TokenManager owns the SCOM session tokens. It refreshes them in the background before the
+// session expires and coalesces concurrent refreshes into a single authentication call.
+type TokenManager struct {
+	settings      *models.PluginSettings
+	authenticate  func(context.Context, *models.PluginSettings) (AuthTokens, error)
+	lifetime      time.Duration
+	timeout       time.Duration
+	minExpiredAge time.Duration
+
+	mu       sync.Mutex
+	tokens   AuthTokens
+	issuedAt time.Time
+	refresh  singleflight.Group
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewTokenManager authenticates against SCOM and starts refreshing the session in the background.
+func NewTokenManager(ctx context.Context, settings *models.PluginSettings) (*TokenManager, error) {
+	return newTokenManager(ctx, settings, Authenticate, time.Duration(settings.SessionTimeout)*time.Minute)
+}
+
+func newTokenManager(ctx context.Context, settings *models.PluginSettings, authenticate func(context.Context, *models.PluginSettings) (AuthTokens, error), lifetime time.Duration) (*TokenManager, error) {
+	tokens, err := authenticate(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &TokenManager{
+		settings:      settings,
+		authenticate:  authenticate,
+		lifetime:      lifetime,
+		timeout:       authenticateTimeout,
+		minExpiredAge: minExpiredSessionAge,
+		tokens:        tokens,
+		issuedAt:      time.Now(),
+		done:          make(chan struct{}),
+	}
+
+	go m.run()
+
+	return m, nil
+}
+
+// Tokens returns the current session tokens.
+func (m *TokenManager) Tokens() AuthTokens {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.tokens
+}
+
+// Age returns how long ago the current session tokens were issued.
+func (m *TokenManager) Age() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return time.Since(m.issuedAt)
+}
+
+// Refresh replaces stale with new session tokens. If the tokens were already replaced by another
+// caller the current ones are returned, and if a refresh is in flight the caller waits for it
+// until ctx is done.
+func (m *TokenManager) Refresh(ctx context.Context, stale AuthTokens) (AuthTokens, error) {
+	m.mu.Lock()
+	if m.tokens != stale {
+		tokens := m.tokens
+		m.mu.Unlock()
+		return tokens, nil
+	}
+
+	m.mu.Unlock()
+
+	// Authenticate without holding the lock so readers keep using the current tokens. The refresh
+	// is shared with other callers, so it must not be cancelled together with the initiating request,
+	// but it is bounded so a hanging SCOM fails every waiting caller instead of blocking them.
+	result := m.refresh.DoChan("", func() (interface{}, error) {
+		m.mu.Lock()
+		if m.tokens != stale {
+			tokens := m.tokens
+			m.mu.Unlock()
+			return tokens, nil
+		}
+		m.mu.Unlock()
+
+		authCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.timeout)
+		defer cancel()
+
+		tokens, err := m.authenticate(authCtx, m.settings)
+		if err != nil {
+			return AuthTokens{}, err
+		}
+
+		m.mu.Lock()
+		m.tokens = tokens
+		m.issuedAt = time.Now()
+		m.mu.Unlock()
+
+		return tokens, nil
+	})
+
+	select {
+	case r := <-result:
+		if r.Err != nil {
+			return AuthTokens{}, r.Err
+		}
+		return r.Val.(AuthTokens), nil
+	case <-ctx.Done():
+		return AuthTokens{}, ctx.Err()
+	}
+}
+
+// Stop ends the background refresh.
+func (m *TokenManager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+}
+
+func (m *TokenManager) run() {
+	if m.lifetime <= 0 {
+		return
+	}
+
+	refreshAfter := time.Duration(float64(m.lifetime) * tokenRefreshRatio)
+
+	for {
+		wait := refreshAfter - m.Age()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-m.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := m.Refresh(context.Background(), m.Tokens()); err != nil {
+			backend.Logger.Warn("Background session refresh failed", "error", err)
+
+			select {
+			case <-m.done:
+				return
+			case <-time.After(tokenRefreshRetryDelay):
+			}
+		}
+	}
+}
+
+// SessionExpired reports whether SCOM rejected a request made with tokens because the session is no
+// longer valid. 440 is the login timeout of the web console. 401 only counts when the tokens were
+// replaced since, or when the session has been in use for a while; a 401 on a fresh session, like a
+// 403, is a denial that a new session would not change.
+func (m *TokenManager) SessionExpired(resp *http.Response, tokens AuthTokens) bool {
+	switch resp.StatusCode {
+	case 440:
+		return true
+	case http.StatusUnauthorized:
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		return m.tokens != tokens || time.Since(m.issuedAt) >= m.minExpiredAge
+	}
+
+	return false
+}
@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/opslogix/scom-plugin-by-opslogix/pkg/models"
+)
+
+func countingAuthenticate(calls *int32, delay time.Duration) func(context.Context, *models.PluginSettings) (AuthTokens, error) {
+	return func(context.Context, *models.PluginSettings) (AuthTokens, error) {
+		n := atomic.AddInt32(calls, 1)
+		time.Sleep(delay)
+		return AuthTokens{SessionID: fmt.Sprintf("SCOMSessionId=%d", n), CSRFToken: "csrf", AuthToken: "basic"}, nil
+	}
+}
+
+func TestTokenManagerCoalescesRefreshes(t *testing.T) {
+	var calls int32
+	m, err := newTokenManager(context.Background(), &models.PluginSettings{}, countingAuthenticate(&calls, 20*time.Millisecond), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	stale := m.Tokens()
+
+	var wg sync.WaitGroup
+	results := make([]AuthTokens, 50)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tokens, err := m.Refresh(context.Background(), stale)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = tokens
+		}(i)
+	}
+	wg.Wait()
+
+	if calls != 2 {
+		t.Fatalf("expected one initial and one refresh authentication, got %d", calls)
+	}
+	for _, tokens := range results {
+		if tokens != m.Tokens() {
+			t.Fatalf("caller received %v, want %v", tokens, m.Tokens())
+		}
+	}
+}
+
+func TestTokenManagerRefreshesBeforeExpiry(t *testing.T) {
+	var calls int32
+	m, err := newTokenManager(context.Background(), &models.PluginSettings{}, countingAuthenticate(&calls, 0), 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&calls) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("session was not refreshed in the background")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTokenManagerRefreshTimesOut(t *testing.T) {
+	hang := false
+	authenticate := func(ctx context.Context, _ *models.PluginSettings) (AuthTokens, error) {
+		if !hang {
+			return AuthTokens{SessionID: "SCOMSessionId=1"}, nil
+		}
+		<-ctx.Done()
+		return AuthTokens{}, ctx.Err()
+	}
+
+	m, err := newTokenManager(context.Background(), &models.PluginSettings{}, authenticate, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	hang = true
+	m.timeout = 20 * time.Millisecond
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := m.Refresh(context.Background(), m.Tokens())
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected the hanging refresh to fail")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("refresh did not time out")
+	}
+}
@@ -1,18 +1,62 @@
-import { Box, Button, FieldSet, InlineField, Input } from '@grafana/ui';
+import { Box, Button, FieldSet, InlineField, Input, MultiSelect, RadioButtonGroup, Select, TagsInput } from '@grafana/ui';
 import React, { useState } from 'react';
 import { useDs } from './providers/ds.provider';
-import { AlertQuery } from 'types';
+import { AlertAggregationQuery, AlertGroupBy, AlertQuery } from 'types';
+import { SelectableValue } from '@grafana/data';
+
+type AlertView = 'alerts' | 'alertAggregation' | 'alertAnnotations';
+
+const viewOptions: Array<SelectableValue<AlertView>> = [
+    { label: 'Alerts', value: 'alerts', description: 'A row per alert' },
+    { label: 'Counts', value: 'alertAggregation', description: 'Alert counts per group' },
+    { label: 'Annotations', value: 'alertAnnotations', description: 'Alerts as annotations from raised to resolved' },
+];
+
+const timeFilterOptions: Array<SelectableValue<AlertQuery['timeFilter']>> = [
+    { label: 'Raised in range', value: 'raised' },
+    { label: 'Modified in range', value: 'modified' },
+];
+
+const groupByOptions: Array<SelectableValue<AlertGroupBy>> = [
+    { label: 'Severity', value: 'severity' },
+    { label: 'Resolution state', value: 'resolutionstate' },
+    { label: 'Object', value: 'object' },
+    { label: 'Class', value: 'class' },
+    { label: 'Name', value: 'name' },
+];
 
 export default function AlertsSection() {
     const { getAlerts, query } = useDs();
 
     const alertQuery = query as AlertQuery;
 
+    const [view, setView] = useState<AlertView>(
+        query.type === 'alertAggregation' || query.type === 'alertAnnotations' ? query.type : 'alerts'
+    );
     const [criteria, setCriteria] = useState(alertQuery.criteria);
+    const [timeFilter, setTimeFilter] = useState(alertQuery.timeFilter);
+    const [columns, setColumns] = useState<string[]>(alertQuery.columns ?? []);
+    const [groupBy, setGroupBy] = useState<AlertGroupBy[]>((query as AlertAggregationQuery).groupBy ?? ['severity']);
+
+    const onSearch = () => {
+        const base = { refId: query.refId, criteria: criteria ?? '', timeFilter, filter: alertQuery.filter };
+
+        switch (view) {
+            case 'alertAggregation':
+                return getAlerts({ ...base, type: view, groupBy, columns: undefined });
+            case 'alertAnnotations':
+                return getAlerts({ ...base, type: view });
+        }
+
+        return getAlerts({ ...base, type: 'alerts', columns: columns.length > 0 ? columns : undefined });
+    };
 
     return (
         <Box padding={1} paddingTop={2}>
             <FieldSet>
+                <InlineField label="View" labelWidth={16}>
+                    <RadioButtonGroup<AlertView> options={viewOptions} value={view} onChange={setView} />
+                </InlineField>
                 <InlineField label="Criteria" labelWidth={16}>
                     <Input
                         onChange={(v) => setCriteria(v.currentTarget.value)}
@@ -21,8 +65,40 @@ export default function AlertsSection() {
                         className="alertsInput"
                     />
                 </InlineField>
+                <InlineField label="Time filter" labelWidth={16} tooltip="Keep alerts raised or last modified within the time range">
+                    <Select<AlertQuery['timeFilter']>
+                        width={24}
+                        options={timeFilterOptions}
+                        value={timeFilter}
+                        placeholder="None"
+                        isClearable
+                        onChange={(v) => setTimeFilter(v?.value)}
+                    />
+                </InlineField>
+                {
+                    view === 'alerts' && (
+                        <InlineField label="Columns" labelWidth={16} tooltip="Alert properties returned on top of the defaults, e.g. owner, ticketid or customfield1">
+                            <TagsInput tags={columns} onChange={setColumns} placeholder="Add column and press enter" />
+                        </InlineField>
+                    )
+                }
+                {
+                    view === 'alertAggregation' && (
+                        <InlineField label="Group by" labelWidth={16}>
+                            <MultiSelect<AlertGroupBy>
+                                options={groupByOptions}
+                                value={groupBy}
+                                onChange={(v) => setGroupBy(v.map((option) => option.value!))}
+                            />
+                        </InlineField>
+                    )
+                }
                 <InlineField>
-                    <Button variant="secondary" icon="search" onClick={() => getAlerts(criteria ?? '')}>
+                    <Button
+                        variant="secondary"
+                        icon="search"
+                        disabled={view === 'alertAggregation' && groupBy.length === 0}
+                        onClick={onSearch}>
                         Search
                     </Button>
                 </InlineField>
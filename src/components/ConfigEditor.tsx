@@ -1,7 +1,12 @@
 import React, { ChangeEvent, useState } from 'react';
-import { Checkbox, InlineField, Input } from '@grafana/ui';
-import { DataSourcePluginOptionsEditorProps } from '@grafana/data';
-import { ScomDataSourceOptions } from '../types';
+import { Checkbox, InlineField, InlineSwitch, Input, Select } from '@grafana/ui';
+import { DataSourcePluginOptionsEditorProps, SelectableValue } from '@grafana/data';
+import { AuthMode, ScomDataSourceOptions } from '../types';
+
+const authModeOptions: Array<SelectableValue<AuthMode>> = [
+  { label: 'Basic', value: 'basic', description: 'User name and password posted to the SCOM authenticate endpoint' },
+  { label: 'Windows (NTLM/Negotiate)', value: 'windows', description: 'Windows integrated authentication' },
+];
 
 interface Props extends DataSourcePluginOptionsEditorProps<ScomDataSourceOptions> {}
 
@@ -27,6 +32,14 @@ export function ConfigEditor(props: Props) {
     onOptionsChange({ ...options, jsonData });
   };
 
+  const onAuthModeChange = (option: SelectableValue<AuthMode>) => {
+    const jsonData = {
+      ...options.jsonData,
+      authMode: option.value,
+    };
+    onOptionsChange({ ...options, jsonData });
+  };
+
   const onPasswordChange = (event: ChangeEvent<HTMLInputElement>) => {
     onOptionsChange({
       ...options,
@@ -38,6 +51,30 @@ export function ConfigEditor(props: Props) {
     setPasswordCopy(event.target.value);
   };
 
+  const onSessionTimeoutChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const jsonData = {
+      ...options.jsonData,
+      sessionTimeout: parseInt(event.target.value, 10) || undefined,
+    };
+    onOptionsChange({ ...options, jsonData });
+  };
+
+  const onMaxConcurrencyChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const jsonData = {
+      ...options.jsonData,
+      maxConcurrency: parseInt(event.target.value, 10) || undefined,
+    };
+    onOptionsChange({ ...options, jsonData });
+  };
+
+  const onServerExtensionChange = (event: React.FormEvent<HTMLInputElement>) => {
+    const jsonData = {
+      ...options.jsonData,
+      serverExtension: event.currentTarget.checked,
+    };
+    onOptionsChange({ ...options, jsonData });
+  };
+
   const onCheck = (event: ChangeEvent<HTMLInputElement>) => {
     const jsonData = {
       ...options.jsonData,
@@ -61,6 +98,15 @@ export function ConfigEditor(props: Props) {
         />
       </InlineField>
 
+      <InlineField label="Auth mode" labelWidth={12}>
+        <Select
+          options={authModeOptions}
+          value={jsonData.authMode || 'basic'}
+          onChange={onAuthModeChange}
+          width={40}
+        />
+      </InlineField>
+
       <InlineField label="Username" labelWidth={12}>
         <Input
           onChange={onUsernameChange}
@@ -74,6 +120,42 @@ export function ConfigEditor(props: Props) {
         <Input onChange={onPasswordChange} value={passwordCopy} width={40} type="password" />
       </InlineField>
 
+      <InlineField
+        label="Session timeout"
+        labelWidth={12}
+        tooltip="SCOM web console session lifetime in minutes. Sessions are refreshed before they expire."
+      >
+        <Input
+          onChange={onSessionTimeoutChange}
+          value={jsonData.sessionTimeout || ''}
+          placeholder="20"
+          type="number"
+          width={40}
+        />
+      </InlineField>
+
+      <InlineField
+        label="Concurrency"
+        labelWidth={12}
+        tooltip="Maximum number of parallel requests sent to SCOM when a query covers many objects."
+      >
+        <Input
+          onChange={onMaxConcurrencyChange}
+          value={jsonData.maxConcurrency || ''}
+          placeholder="10"
+          type="number"
+          width={40}
+        />
+      </InlineField>
+
+      <InlineField
+        label="Server extension"
+        labelWidth={12}
+        tooltip="Enables the features that need endpoints outside the SCOM REST API, such as maintenance queries. Requires an extension on the SCOM web console server."
+      >
+        <InlineSwitch value={jsonData.serverExtension || false} onChange={onServerExtensionChange} />
+      </InlineField>
+
       <br />
       <Checkbox
         value={jsonData.isSkipTlsVerifyCheck || checked}
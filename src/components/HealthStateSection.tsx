@@ -1,14 +1,45 @@
-import { AsyncSelect, Box, Button, Field, MultiSelect, RadioButtonGroup, Stack } from '@grafana/ui';
+import { AsyncMultiSelect, Box, Button, Field, InlineSwitch, MultiSelect, RadioButtonGroup, Select, Stack } from '@grafana/ui';
 import React, { useEffect, useState } from 'react';
-import { MonitoringClass, MonitoringGroup, MonitoringObject, StateQuery } from 'types';
+import { AvailabilityQuery, HealthQuery, MonitoringClass, MonitoringGroup, MonitoringObject, ObjectSelection, StateHistoryQuery } from 'types';
 import { useDs } from './providers/ds.provider';
 import { SelectableValue } from '@grafana/data';
- 
+
+const viewOptions: Array<SelectableValue<HealthQuery['type']>> = [
+    { label: 'Health state', value: 'state', description: 'Current health state of every object' },
+    { label: 'Health explorer', value: 'healthExplorer', description: 'Monitor rollup tree, also for the node graph panel' },
+    { label: 'State history', value: 'stateHistory', description: 'Health state changes over the time range, for the state timeline panel' },
+    { label: 'Availability', value: 'availability', description: 'Share of the time range spent in every health state' },
+    { label: 'Maintenance', value: 'maintenance', description: 'Maintenance mode windows as annotation regions' },
+];
+
+// Views calling endpoints of the server extension.
+const extensionViews: Array<HealthQuery['type']> = ['stateHistory', 'availability', 'maintenance'];
+
+type StateHistoryLevel = NonNullable<StateHistoryQuery['level']>;
+
+const levelOptions: Array<SelectableValue<StateHistoryLevel>> = [
+    { label: 'Object', value: 'object' },
+    { label: 'Monitor', value: 'monitor' },
+];
+
+const allInstances: MonitoringObject = {
+    id: '*',
+    displayName: '*',
+    path: '',
+    fullname: 'All Instances',
+    classname: '',
+};
+
 export default function HealthStateSection() {
- 
-    const { query, getState, getStateByGroup, getClasses, getMonitoringObjects, getMonitoringGroups } = useDs();
-    const stateQuery = query as StateQuery;
- 
+
+    const { query, serverExtension, getHealth, getClasses, getMonitoringObjects, getMonitoringGroups } = useDs();
+    const stateQuery = query as HealthQuery;
+
+    const views = viewOptions.filter((o) => serverExtension || !extensionViews.includes(o.value!));
+    const [view, setView] = useState<HealthQuery['type']>(views.some((o) => o.value === query.type) ? stateQuery.type : 'state');
+    const [level, setLevel] = useState<StateHistoryLevel>((query as StateHistoryQuery).level ?? 'object');
+    const [excludeMaintenance, setExcludeMaintenance] = useState((query as AvailabilityQuery).excludeMaintenance ?? false);
+
     const options: SelectableValue[] = [{
         label: 'Class',
         value: 'class'
@@ -16,141 +47,164 @@ export default function HealthStateSection() {
         label: 'Group',
         value: 'group'
     }]
- 
+
     const [selectedCategory, setSelectedCategory] = useState<string>();
- 
-    const [selectedClass, setSelectedClass] = useState<MonitoringClass>();
-    const [selectedGroupClass, setSelectedGroupClass] = useState<MonitoringClass>();
- 
+
+    const [selectedClasses, setSelectedClasses] = useState<MonitoringClass[]>([]);
+    const [selectedGroupClasses, setSelectedGroupClasses] = useState<MonitoringClass[]>([]);
+
     const [classInstances, setClassInstances] = useState<MonitoringObject[]>([]);
     const [selectedInstances, setSelectedInstances] = useState<MonitoringObject[]>([]);
- 
-    const [selectedGroup, setSelectedGroup] = useState<MonitoringGroup>();
- 
+
+    const [selectedGroups, setSelectedGroups] = useState<MonitoringGroup[]>([]);
+
     const [monitoringGroups] = useState<Promise<MonitoringGroup[]>>(getMonitoringGroups);
     const [monitoringClasses] = useState<Promise<MonitoringClass[]>>(getClasses(''));
- 
+
+    // Instances of every class, each object once, after the wildcard.
+    const loadClassInstances = async (classes: MonitoringClass[]) => {
+        if (classes.length === 0) {
+            setClassInstances([]);
+            return;
+        }
+
+        const instances = await Promise.all(classes.map((cls) => getMonitoringObjects(cls.className)));
+        const unique = new Map<string, MonitoringObject>();
+        instances.flat().forEach((obj) => unique.set(obj.id, obj));
+
+        setClassInstances([allInstances, ...unique.values()]);
+    }
+
     useEffect(() => {
         if (!stateQuery) {
             return;
         }
- 
+
         const initialize = async () => {
+            const classes = stateQuery.classes ?? [];
+
             if (stateQuery.groups && stateQuery.groups.length > 0) {
-                setSelectedGroup(stateQuery.groups.at(0));
+                setSelectedGroups(stateQuery.groups);
+                setSelectedGroupClasses(classes);
                 setSelectedCategory("group");
-            } else {
-                setSelectedCategory("class")
-            }
- 
-            const selectedClass = stateQuery.classes?.[0];
- 
-            if (selectedClass) {
-                setClassInstances([{
-                    id: '*',
-                    displayName: '*',
-                    path: '',
-                    fullname: 'All Instances',
-                    classname: '',
-                }, ...await getMonitoringObjects(selectedClass.className)])
-                setSelectedClass(selectedClass);
+                return;
             }
- 
+
+            setSelectedCategory("class")
+            setSelectedClasses(classes);
+            await loadClassInstances(classes);
+
             if (stateQuery.instances && stateQuery.instances.length > 0) {
                 setSelectedInstances(stateQuery.instances);
             }
         }
- 
+
         initialize();
- 
+
         // eslint-disable-next-line react-hooks/exhaustive-deps
     }, [])
- 
-    const onClassSelect = async (v?: MonitoringClass | undefined) => {
-        if (!v) {
-            return;
-        }
- 
-        setSelectedClass(v);
+
+    const onClassSelect = async (v: MonitoringClass[]) => {
+        setSelectedClasses(v);
         setSelectedInstances([]);
- 
-        const instances = await getMonitoringObjects(v.className)
-        setClassInstances([
-            {
-                id: '*',
-                displayName: '*',
-                path: '',
-                fullname: 'All Instances',
-                classname: '',
-            },
-            ...instances
-        ]);
+
+        await loadClassInstances(v);
     }
- 
+
     const onInstanceSelect = async (v?: MonitoringObject[]) => {
         if (!v) {
             return;
         }
- 
+
         const wildcardMonitoringObject = v.filter(obj => obj.id === '*');
         const isAllSelected = wildcardMonitoringObject.length > 0;
- 
+
         if (isAllSelected) {
             setSelectedInstances(wildcardMonitoringObject)
         } else {
             setSelectedInstances(v);
         }
     }
- 
-    const onGroupClassSelect = async (v: MonitoringClass) => {
-        if (!v) {
-            return
-        }
- 
-        setSelectedGroupClass(v);
-    }
- 
+
     const onCategoryChange = async (option: string) => {
         setSelectedCategory(option)
     }
- 
+
+    const onSearch = (selection: ObjectSelection) => {
+        const base = { refId: query.refId, ...selection };
+
+        switch (view) {
+            case 'stateHistory':
+                return getHealth({ ...base, type: view, level });
+            case 'availability':
+                return getHealth({ ...base, type: view, excludeMaintenance });
+        }
+
+        return getHealth({ ...base, type: view });
+    }
+
     const loadClassOptions = async (inputValue: string): Promise<MonitoringClass[]> => {
         const classes = await monitoringClasses;
- 
+
         return classes.filter((monitoringClass) => monitoringClass.displayName.toLowerCase().includes(inputValue.toLowerCase()));
     }
- 
+
     const loadGroupOptions = async (inputValue: string): Promise<MonitoringGroup[]> => {
         const groups = await monitoringGroups;
         return groups.filter((g) => g.displayName.toLowerCase().includes(inputValue.toLowerCase()));
     }
- 
+
     const loadGroupClassOptions = async (inputValue: string): Promise<MonitoringClass[]> => {
         const classes = await monitoringClasses;
         const groups = await monitoringGroups;
         return classes.filter((monitoringClass) => !groups.some((group) => group.id === monitoringClass.id) && monitoringClass.displayName.toLowerCase().includes(inputValue.toLowerCase()));
     }
- 
+
     return (
         <>
             <Box padding={1} paddingTop={2}>
-                <RadioButtonGroup
-                    options={options}
-                    value={selectedCategory}
-                    onChange={onCategoryChange} />
+                <Stack direction={'row'} alignItems={'center'}>
+                    <RadioButtonGroup
+                        options={options}
+                        value={selectedCategory}
+                        onChange={onCategoryChange} />
+                    <Select<HealthQuery['type']>
+                        width={24}
+                        options={views}
+                        value={view}
+                        onChange={(v) => setView(v.value!)} />
+                    {
+                        view === 'stateHistory' && (
+                            <RadioButtonGroup<StateHistoryLevel>
+                                options={levelOptions}
+                                value={level}
+                                onChange={setLevel} />
+                        )
+                    }
+                    {
+                        view === 'availability' && (
+                            <InlineSwitch
+                                label="Exclude maintenance"
+                                showLabel
+                                value={excludeMaintenance}
+                                onChange={(e) => setExcludeMaintenance(e.currentTarget.checked)} />
+                        )
+                    }
+                </Stack>
             </Box>
             <Box padding={1}>
                 <Stack direction={'column'} width={'auto'}>
                     {
                         selectedCategory === 'class' ? (
                             <>
-                                <Field label="Class">
-                                    <AsyncSelect<MonitoringClass>
+                                <Field label="Classes">
+                                    <AsyncMultiSelect<MonitoringClass>
                                         defaultOptions
                                         loadOptions={loadClassOptions}
                                         getOptionLabel={(v) => v.displayName}
-                                        value={selectedClass}
-                                        onChange={(v) => onClassSelect(v as MonitoringClass)}
+                                        getOptionValue={(v) => v.id}
+                                        value={selectedClasses}
+                                        onChange={(v) => onClassSelect(v as MonitoringClass[])}
                                     />
                                 </Field>
                                 <Field label="Instances">
@@ -158,14 +212,14 @@ export default function HealthStateSection() {
                                         options={classInstances}
                                         value={selectedInstances}
                                         getOptionLabel={(v) => v.displayName}
-                                        getOptionValue={(v) => v.displayName}
+                                        getOptionValue={(v) => v.id}
                                         onChange={(v) => onInstanceSelect(v as MonitoringObject[])} />
- 
+
                                 </Field>
                                 {
-                                    selectedClass && selectedInstances.length > 0 && (
+                                    selectedClasses.length > 0 && selectedInstances.length > 0 && (
                                         <Field>
-                                            <Button variant="secondary" icon="search" onClick={() => getState([selectedClass], selectedInstances)}>
+                                            <Button variant="secondary" icon="search" onClick={() => onSearch({ classes: selectedClasses, groups: undefined, instances: selectedInstances })}>
                                                 Search
                                             </Button>
                                         </Field>
@@ -175,26 +229,28 @@ export default function HealthStateSection() {
                         ) : (
                             <>
                                 <Field label="Groups">
-                                    <AsyncSelect<MonitoringGroup>
+                                    <AsyncMultiSelect<MonitoringGroup>
                                         defaultOptions
                                         loadOptions={loadGroupOptions}
-                                        value={selectedGroup}
+                                        value={selectedGroups}
                                         getOptionLabel={(v) => v.displayName}
-                                        onChange={(v) => setSelectedGroup(v as MonitoringGroup)}
+                                        getOptionValue={(v) => v.id}
+                                        onChange={(v) => setSelectedGroups(v as MonitoringGroup[])}
                                     />
                                 </Field>
-                                <Field label="Class">
-                                    <AsyncSelect<MonitoringClass>
+                                <Field label="Classes" description="Optional, limits the group members to these classes">
+                                    <AsyncMultiSelect<MonitoringClass>
                                         defaultOptions
                                         loadOptions={loadGroupClassOptions}
                                         getOptionLabel={(v) => v.displayName}
-                                        value={selectedGroupClass}
-                                        onChange={(v) => onGroupClassSelect(v as MonitoringClass)}
+                                        getOptionValue={(v) => v.id}
+                                        value={selectedGroupClasses}
+                                        onChange={(v) => setSelectedGroupClasses(v as MonitoringClass[])}
                                     />
                                 </Field>
                                 {
-                                    selectedGroup && selectedGroupClass && (
-                                        <Button variant="secondary" icon="search" onClick={() => getStateByGroup(selectedGroup, [selectedGroupClass])}>
+                                    selectedGroups.length > 0 && (
+                                        <Button variant="secondary" icon="search" onClick={() => onSearch({ classes: selectedGroupClasses, groups: selectedGroups, instances: undefined })}>
                                             Search
                                         </Button>
                                     )
@@ -206,4 +262,3 @@ export default function HealthStateSection() {
         </>
     );
 }
-                             
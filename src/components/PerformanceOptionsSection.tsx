@@ -0,0 +1,152 @@
+import React from 'react';
+import { InlineField, InlineFieldRow, Input, RadioButtonGroup, Select } from '@grafana/ui';
+import { SelectableValue } from '@grafana/data';
+import { Aggregation, PerformanceFormat, PerformanceOptions, PerformanceRanking, PerformanceRollup } from 'types';
+
+const modeOptions: Array<SelectableValue<NonNullable<PerformanceOptions['mode']>>> = [
+  { label: 'Series', value: 'series' },
+  { label: 'Summary', value: 'summary', description: 'Min, max, average and last value per series' },
+];
+
+const formatOptions: Array<SelectableValue<PerformanceFormat>> = [
+  { label: 'Table', value: 'table', description: 'A frame per series with object and counter columns' },
+  { label: 'Time series', value: 'timeseries', description: 'A labelled frame per series' },
+  { label: 'Wide', value: 'wide', description: 'A single frame with a field per series' },
+];
+
+const aggregationOptions: Array<SelectableValue<Aggregation>> = [
+  { label: 'None', value: 'none', description: 'Return every sample' },
+  { label: 'Average', value: 'avg' },
+  { label: 'Min', value: 'min' },
+  { label: 'Max', value: 'max' },
+  { label: 'Last', value: 'last' },
+  { label: 'Sum', value: 'sum' },
+  { label: 'Count', value: 'count' },
+];
+
+const rankingOrderOptions: Array<SelectableValue<PerformanceRanking['order']>> = [
+  { label: 'Top', value: 'top' },
+  { label: 'Bottom', value: 'bottom' },
+];
+
+const rankingByOptions: Array<SelectableValue<PerformanceRanking['by']>> = [
+  { label: 'Average', value: 'avg' },
+  { label: 'Max', value: 'max' },
+  { label: 'Last', value: 'last' },
+];
+
+const rollupOptions: Array<SelectableValue<PerformanceRollup['aggregation']>> = [
+  { label: 'Average', value: 'avg' },
+  { label: 'Sum', value: 'sum' },
+  { label: 'Min', value: 'min' },
+  { label: 'Max', value: 'max' },
+  { label: 'Percentile', value: 'percentile' },
+];
+
+interface Props {
+  value: PerformanceOptions;
+  onChange: (options: PerformanceOptions) => void;
+  /** Rollup only applies to group selections. */
+  showRollup: boolean;
+}
+
+export default function PerformanceOptionsSection({ value, onChange, showRollup }: Props) {
+  const update = (options: PerformanceOptions) => onChange({ ...value, ...options });
+
+  return (
+    <>
+      <InlineFieldRow>
+        <InlineField label="Mode" labelWidth={14}>
+          <RadioButtonGroup options={modeOptions} value={value.mode ?? 'series'} onChange={(mode) => update({ mode })} />
+        </InlineField>
+        {value.mode !== 'summary' && (
+          <>
+            <InlineField label="Format" labelWidth={14}>
+              <Select<PerformanceFormat>
+                width={20}
+                options={formatOptions}
+                value={value.format ?? 'table'}
+                onChange={(v) => update({ format: v.value })}
+              />
+            </InlineField>
+            <InlineField
+              label="Aggregation"
+              labelWidth={14}
+              tooltip="Applied per interval when resampling. Without one, series are averaged only when they have more samples than the panel can show"
+            >
+              <Select<Aggregation>
+                width={20}
+                options={aggregationOptions}
+                value={value.aggregation}
+                placeholder="Auto"
+                isClearable
+                onChange={(v) => update({ aggregation: v?.value })}
+              />
+            </InlineField>
+          </>
+        )}
+      </InlineFieldRow>
+      <InlineFieldRow>
+        <InlineField label="Ranking" labelWidth={14} tooltip="Keep only the highest or lowest ranked series">
+          <Select<PerformanceRanking['order']>
+            width={20}
+            options={rankingOrderOptions}
+            value={value.ranking?.order}
+            placeholder="All series"
+            isClearable
+            onChange={(v) =>
+              update({ ranking: v?.value ? { limit: 5, by: 'avg', ...value.ranking, order: v.value } : undefined })
+            }
+          />
+        </InlineField>
+        {value.ranking && (
+          <>
+            <InlineField label="Limit" labelWidth={10}>
+              <Input
+                type="number"
+                width={10}
+                min={1}
+                value={value.ranking.limit}
+                onChange={(e) => update({ ranking: { ...value.ranking!, limit: Number(e.currentTarget.value) } })}
+              />
+            </InlineField>
+            <InlineField label="By" labelWidth={10}>
+              <Select<PerformanceRanking['by']>
+                width={16}
+                options={rankingByOptions}
+                value={value.ranking.by}
+                onChange={(v) => update({ ranking: { ...value.ranking!, by: v.value! } })}
+              />
+            </InlineField>
+          </>
+        )}
+      </InlineFieldRow>
+      {showRollup && (
+        <InlineFieldRow>
+          <InlineField label="Rollup" labelWidth={14} tooltip="Aggregate the members of every group into a single series per counter">
+            <Select<PerformanceRollup['aggregation']>
+              width={20}
+              options={rollupOptions}
+              value={value.rollup?.aggregation}
+              placeholder="Per member"
+              isClearable
+              onChange={(v) => update({ rollup: v?.value ? { percentile: 95, ...value.rollup, aggregation: v.value } : undefined })}
+            />
+          </InlineField>
+          {value.rollup?.aggregation === 'percentile' && (
+            <InlineField label="Percentile" labelWidth={12}>
+              <Input
+                type="number"
+                width={10}
+                min={0}
+                max={100}
+                value={value.rollup.percentile}
+                onChange={(e) => update({ rollup: { ...value.rollup!, percentile: Number(e.currentTarget.value) } })}
+              />
+            </InlineField>
+          )}
+        </InlineFieldRow>
+      )}
+    </>
+  );
+}
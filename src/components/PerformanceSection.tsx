@@ -1,9 +1,29 @@
 import React, { useEffect, useState } from 'react';
-import { AsyncSelect, Box, Button, Field, MultiSelect, RadioButtonGroup, Select, Stack } from '@grafana/ui';
+import { AsyncMultiSelect, Box, Button, Field, MultiSelect, RadioButtonGroup, Stack } from '@grafana/ui';
 import { useDs } from './providers/ds.provider';
-import { MonitoringClass, MonitoringGroup, MonitoringObject, PerformanceCounter, PerformanceQuery } from 'types';
+import { MonitoringClass, MonitoringGroup, MonitoringObject, PerformanceCounter, PerformanceOptions, PerformanceQuery } from 'types';
+import PerformanceOptionsSection from './PerformanceOptionsSection';
 import { SelectableValue } from '@grafana/data';
 
+const counterLabel = (v: PerformanceCounter) =>
+  `${v.counterName} - ${v.objectName}${v.instanceName ? ` - ${v.instanceName}` : ''}`;
+
+const counterKey = (v: PerformanceCounter) => `${v.objectName}/${v.counterName}/${v.instanceName}`;
+
+const allMonitoringObject: MonitoringObject = {
+  id: '*',
+  displayName: '*',
+  path: '',
+  fullname: 'All Monitoring Objects',
+  classname: '',
+};
+
+const allOption: SelectableValue<MonitoringObject> = {
+  label: 'All',
+  value: allMonitoringObject,
+  ...allMonitoringObject,
+};
+
 export default function PerformanceSection() {
   const { getClasses, getMonitoringObjects, getMonitoringGroups, getPerformanceCounters, getPerformance, query } = useDs();
   const performanceQuery = query as PerformanceQuery;
@@ -13,169 +33,117 @@ export default function PerformanceSection() {
     { label: 'Group', value: 'group' },
   ];
 
+  const [performanceOptions, setPerformanceOptions] = useState<PerformanceOptions>({
+    aggregation: performanceQuery.aggregation,
+    format: performanceQuery.format,
+    mode: performanceQuery.mode,
+    ranking: performanceQuery.ranking,
+    rollup: performanceQuery.rollup,
+  });
+
   const [selectedCategory, setSelectedCategory] = useState<string>();
-  const [selectedClass, setSelectedClass] = useState<MonitoringClass>();
-  const [selectedClassInstances, setSelectedClassInstances] = useState<Array<SelectableValue<MonitoringObject>>>();
-  const [selectedPerformanceCounter, setSelectedPerformanceCounter] = useState<PerformanceCounter>();
+  const [selectedClasses, setSelectedClasses] = useState<MonitoringClass[]>([]);
+  const [selectedClassInstances, setSelectedClassInstances] = useState<MonitoringObject[]>([]);
+  const [selectedPerformanceCounters, setSelectedPerformanceCounters] = useState<PerformanceCounter[]>([]);
 
-  const [selectedGroup, setSelectedGroup] = useState<MonitoringGroup>();
-  const [selectedGroupClass, setSelectedGroupClass] = useState<MonitoringClass>();
-  const [selectedGroupPerformanceCounter, setSelectedGroupPerformanceCounter] = useState<PerformanceCounter>();
+  const [selectedGroups, setSelectedGroups] = useState<MonitoringGroup[]>([]);
+  const [selectedGroupClasses, setSelectedGroupClasses] = useState<MonitoringClass[]>([]);
+  const [selectedGroupPerformanceCounters, setSelectedGroupPerformanceCounters] = useState<PerformanceCounter[]>([]);
   const [performanceCounters, setPerformanceCounters] = useState<PerformanceCounter[]>([]);
-  const [monitoringObjects, setMonitoringObjects] = useState<Array<SelectableValue<MonitoringObject>>>();
+  const [monitoringObjects, setMonitoringObjects] = useState<Array<SelectableValue<MonitoringObject>>>([]);
 
   const [monitoringGroups] = useState<Promise<MonitoringGroup[]>>(getMonitoringGroups);
   const [monitoringClasses] = useState<Promise<MonitoringClass[]>>(getClasses(''));
 
+  // Instances of every class, each object once.
+  const getClassInstances = async (classes: MonitoringClass[]): Promise<MonitoringObject[]> => {
+    const instances = await Promise.all(classes.map((cls) => getMonitoringObjects(cls.className)));
+    const unique = new Map<string, MonitoringObject>();
+    instances.flat().forEach((obj) => unique.set(obj.id, obj));
+
+    return [...unique.values()];
+  };
+
+  const loadCounters = async (instances: MonitoringObject[]) => {
+    setPerformanceCounters(instances.length ? await getPerformanceCounters(instances.map((x) => x.id)) : []);
+  };
+
   useEffect(() => {
     if (!performanceQuery) {
       return;
     }
 
     const initialize = async () => {
-
-      if (performanceQuery.instances) {
-        setSelectedClassInstances(performanceQuery.instances);
-        if (performanceQuery.instances[0].id === '*') {
-          const a = performanceQuery.classes?.at(0)?.className
-          if (a) {
-            const allInstances = await getMonitoringObjects(a)
-            if (allInstances?.length) {
-              console.log('s  ')
-              setPerformanceCounters(await getPerformanceCounters([allInstances[0].id]));
-            }
-          }
-        } else {
-          setPerformanceCounters(await getPerformanceCounters(performanceQuery.instances.map((x) => x.id)));
-        }
-      }
+      const classes = performanceQuery.classes ?? [];
 
       if (performanceQuery.groups?.length) {
-        setSelectedGroup(performanceQuery.groups[0]);
-        setSelectedGroupClass(performanceQuery.classes?.[0]);
+        setSelectedGroups(performanceQuery.groups);
+        setSelectedGroupClasses(classes);
         setSelectedCategory('group');
-        setSelectedGroupPerformanceCounter(performanceQuery.counters?.[0]);
-      } else {
-        const selectedCls = performanceQuery.classes?.[0];
-        if (selectedCls) {
-          setSelectedClass(selectedCls);
-          const objs = await getMonitoringObjects(selectedCls.className);
-
-          const allMonitoringObject: MonitoringObject = {
-            id: '*',
-            displayName: '*',
-            path: '',
-            fullname: 'All Instances',
-            classname: '',
-          };
-
-          const allOption: SelectableValue<MonitoringObject> = {
-            label: 'All',
-            value: allMonitoringObject,
-            ...allMonitoringObject,
-          };
-
-          setMonitoringObjects([allOption, ...objs]);
-        }
-        setSelectedCategory('class');
-        if (performanceQuery.instances?.length) {
-          setSelectedClassInstances(performanceQuery.instances);
-        }
-        setSelectedPerformanceCounter(performanceQuery.counters?.[0]);
+        setSelectedGroupPerformanceCounters(performanceQuery.counters ?? []);
+        await loadCounters(await getClassInstances(classes));
+        return;
       }
+
+      setSelectedCategory('class');
+      setSelectedClasses(classes);
+      setSelectedPerformanceCounters(performanceQuery.counters ?? []);
+
+      const objs = await getClassInstances(classes);
+      setMonitoringObjects(objs.length ? [allOption, ...objs] : []);
+
+      const instances = performanceQuery.instances ?? [];
+      setSelectedClassInstances(instances);
+      await loadCounters(instances.some((obj) => obj.id === '*') ? objs : instances);
     };
 
     initialize();
     // eslint-disable-next-line react-hooks/exhaustive-deps
   }, []);
 
-  const onClassSelect = async (v?: MonitoringClass) => {
-
-    if (!v) {
-      return;
-    }
-
-    setSelectedClass(v);
+  const onClassSelect = async (v: MonitoringClass[]) => {
+    setSelectedClasses(v);
     setSelectedClassInstances([]);
-    setSelectedPerformanceCounter(undefined);
-
-    const objs = await getMonitoringObjects(v.className);
-    if (objs.length === 0) {
-      return setMonitoringObjects([objs]);
-    }
+    setSelectedPerformanceCounters([]);
+    setPerformanceCounters([]);
 
     //Add wildcard monitoring object if there are any objects
-    const allMonitoringObject: MonitoringObject = {
-      id: '*',
-      displayName: '*',
-      path: '',
-      fullname: 'All Monitoring Objects',
-      classname: '',
-    };
-
-    const allOption: SelectableValue<MonitoringObject> = {
-      label: 'All',
-      value: allMonitoringObject,
-      ...allMonitoringObject,
-    };
-
-    return setMonitoringObjects([allOption, ...objs]);
+    const objs = await getClassInstances(v);
+    setMonitoringObjects(objs.length ? [allOption, ...objs] : []);
   };
 
   const onInstanceSelect = async (v?: MonitoringObject[]) => {
-
     if (!v) {
       return;
     }
 
-    const wildcardMonitoringObject = v.filter(obj => obj.id === '*');
-    const isAllSelected = wildcardMonitoringObject.length > 0;
-
-    if (isAllSelected && monitoringObjects) {
+    const wildcardMonitoringObject = v.filter((obj) => obj.id === '*');
 
-      console.log('wildcard used')
-      //Wildcard used, get all actual instances for class and retrieve performance counters
-      const allInstances = monitoringObjects.filter((obj) => obj.id !== '*');
-
-      //Set selected instance to the wildcard object
+    if (wildcardMonitoringObject.length > 0) {
+      //Wildcard used, retrieve performance counters of all actual instances of the classes
       setSelectedClassInstances(wildcardMonitoringObject);
-      setPerformanceCounters(await getPerformanceCounters(allInstances.map((x) => x.id)));
+      await loadCounters(monitoringObjects.filter((obj) => obj.id !== '*') as MonitoringObject[]);
     } else {
-      console.log('test')
       setSelectedClassInstances(v);
-      if (v.length) {
-        setPerformanceCounters(await getPerformanceCounters(v.map((x) => x.id)));
-      }
+      await loadCounters(v);
     }
   };
 
-  const onPerformanceCounterSelect = async (v?: PerformanceCounter) => {
-    if (v) {
-      setSelectedPerformanceCounter(v);
-    }
+  const onPerformanceCounterSelect = async (v?: PerformanceCounter[]) => {
+    setSelectedPerformanceCounters(v ?? []);
   };
 
-  const onGroupSelect = async (group?: MonitoringGroup) => {
-    if (group) {
-      setSelectedGroup(group);
-    }
+  const onGroupSelect = async (groups: MonitoringGroup[]) => {
+    setSelectedGroups(groups);
   };
 
-  const onGroupClassSelect = async (v?: MonitoringClass) => {
-    if (!v) {
-      return;
-    }
-    setSelectedGroupClass(v);
-    const classInstances = await getMonitoringObjects(v.className);
-    if (!classInstances?.length) {
-      setPerformanceCounters([]);
-    } else {
-      setPerformanceCounters(await getPerformanceCounters(classInstances.map((x) => x.id)));
-    }
+  const onGroupClassSelect = async (v: MonitoringClass[]) => {
+    setSelectedGroupClasses(v);
+    await loadCounters(await getClassInstances(v));
   };
 
-  const onGroupPerformanceCounterSelect = async (v?: PerformanceCounter) => {
-    setSelectedGroupPerformanceCounter(v);
+  const onGroupPerformanceCounterSelect = async (v?: PerformanceCounter[]) => {
+    setSelectedGroupPerformanceCounters(v ?? []);
   };
 
   const onCategoryChange = async (category: string) => {
@@ -211,24 +179,25 @@ export default function PerformanceSection() {
         <Stack direction="column" width="auto">
           {selectedCategory === 'class' && (
             <>
-              <Field label="Class">
-                <AsyncSelect<MonitoringClass>
+              <Field label="Classes">
+                <AsyncMultiSelect<MonitoringClass>
                   maxMenuHeight={200}
                   defaultOptions
-                  value={selectedClass}
+                  value={selectedClasses}
                   getOptionLabel={(v) => v.displayName}
+                  getOptionValue={(v) => v.id}
                   loadOptions={loadClassOptions}
-                  onChange={(v) => onClassSelect(v as MonitoringClass)}
+                  onChange={(v) => onClassSelect(v as MonitoringClass[])}
                   cacheOptions
                 />
               </Field>
 
-              {selectedClass && (
-                <Field label="Instance">
+              {selectedClasses.length > 0 && (
+                <Field label="Instances">
                   <MultiSelect<MonitoringObject>
                     maxMenuHeight={200}
                     getOptionLabel={(v) => v.displayName}
-                    getOptionValue={(v) => v.displayName}
+                    getOptionValue={(v) => v.id}
                     value={selectedClassInstances}
                     options={monitoringObjects}
                     onChange={(v) => onInstanceSelect(v as MonitoringObject[])}
@@ -236,85 +205,96 @@ export default function PerformanceSection() {
                 </Field>
               )}
 
-              {selectedClassInstances && (
-                <Field label="Counter">
-                  <Select<PerformanceCounter>
-                    getOptionLabel={(v) =>
-                      `${v.counterName} - ${v.objectName}${v.instanceName ? ` - ${v.instanceName}` : ''}`
-                    }
-                    value={selectedPerformanceCounter}
+              {selectedClassInstances.length > 0 && (
+                <Field label="Counters">
+                  <MultiSelect<PerformanceCounter>
+                    getOptionLabel={counterLabel}
+                    getOptionValue={counterKey}
+                    value={selectedPerformanceCounters}
                     options={performanceCounters}
-                    onChange={(v) => onPerformanceCounterSelect(v as PerformanceCounter)}
+                    onChange={(v) => onPerformanceCounterSelect(v as PerformanceCounter[])}
                   />
                 </Field>
               )}
 
-              {selectedPerformanceCounter && selectedClassInstances && selectedClass && (
-                <Field>
-                  <Button
-                    variant="secondary"
-                    icon="thumbs-up"
-                    onClick={() =>
-                      getPerformance([selectedPerformanceCounter], [selectedClass], selectedClassInstances as MonitoringObject[])
-                    }
-                  >
-                    Apply
-                  </Button>
-                </Field>
+              {selectedPerformanceCounters.length > 0 && selectedClassInstances.length > 0 && selectedClasses.length > 0 && (
+                <>
+                  <PerformanceOptionsSection value={performanceOptions} onChange={setPerformanceOptions} showRollup={false} />
+                  <Field>
+                    <Button
+                      variant="secondary"
+                      icon="thumbs-up"
+                      onClick={() =>
+                        getPerformance(selectedPerformanceCounters, selectedClasses, selectedClassInstances, undefined, {
+                          ...performanceOptions,
+                          rollup: undefined,
+                        })
+                      }
+                    >
+                      Apply
+                    </Button>
+                  </Field>
+                </>
               )}
             </>
           )}
 
           {selectedCategory === 'group' && (
             <>
-              <Field label="Group">
-                <AsyncSelect<MonitoringGroup>
+              <Field label="Groups">
+                <AsyncMultiSelect<MonitoringGroup>
                   defaultOptions
                   maxMenuHeight={200}
                   getOptionLabel={(v) => v.displayName}
-                  value={selectedGroup}
+                  getOptionValue={(v) => v.id}
+                  value={selectedGroups}
                   loadOptions={loadGroupOptions}
-                  onChange={(v) => onGroupSelect(v as MonitoringGroup)}
+                  onChange={(v) => onGroupSelect(v as MonitoringGroup[])}
                 />
               </Field>
 
-              {selectedGroup && (
-                <Field label="Class">
-                  <AsyncSelect<MonitoringClass>
+              {selectedGroups.length > 0 && (
+                <Field label="Classes">
+                  <AsyncMultiSelect<MonitoringClass>
                     maxMenuHeight={200}
                     defaultOptions
-                    value={selectedGroupClass}
+                    value={selectedGroupClasses}
                     getOptionLabel={(v) => v.displayName}
+                    getOptionValue={(v) => v.id}
                     loadOptions={loadGroupClassOptions}
-                    onChange={(v) => onGroupClassSelect(v as MonitoringClass)}
+                    onChange={(v) => onGroupClassSelect(v as MonitoringClass[])}
                     cacheOptions
                   />
                 </Field>
               )}
 
-              {selectedGroup && selectedGroupClass && (
-                <Field label="Counter">
-                  <Select<PerformanceCounter>
-                    getOptionLabel={(v) => v.counterName}
-                    value={selectedGroupPerformanceCounter}
+              {selectedGroups.length > 0 && selectedGroupClasses.length > 0 && (
+                <Field label="Counters">
+                  <MultiSelect<PerformanceCounter>
+                    getOptionLabel={counterLabel}
+                    getOptionValue={counterKey}
+                    value={selectedGroupPerformanceCounters}
                     options={performanceCounters}
-                    onChange={(v) => onGroupPerformanceCounterSelect(v as PerformanceCounter)}
+                    onChange={(v) => onGroupPerformanceCounterSelect(v as PerformanceCounter[])}
                   />
                 </Field>
               )}
 
-              {selectedGroup && selectedGroupClass && selectedGroupPerformanceCounter && (
-                <Field>
-                  <Button
-                    variant="secondary"
-                    icon="thumbs-up"
-                    onClick={() =>
-                      getPerformance([selectedGroupPerformanceCounter], [selectedGroupClass], undefined, [selectedGroup])
-                    }
-                  >
-                    Apply
-                  </Button>
-                </Field>
+              {selectedGroups.length > 0 && selectedGroupClasses.length > 0 && selectedGroupPerformanceCounters.length > 0 && (
+                <>
+                  <PerformanceOptionsSection value={performanceOptions} onChange={setPerformanceOptions} showRollup />
+                  <Field>
+                    <Button
+                      variant="secondary"
+                      icon="thumbs-up"
+                      onClick={() =>
+                        getPerformance(selectedGroupPerformanceCounters, selectedGroupClasses, undefined, selectedGroups, performanceOptions)
+                      }
+                    >
+                      Apply
+                    </Button>
+                  </Field>
+                </>
               )}
             </>
           )}
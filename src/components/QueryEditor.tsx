@@ -4,6 +4,11 @@ import PerformanceSection from './PerformanceSection';
 import { useDs } from './providers/ds.provider';
 import AlertsSection from './AlertsSection';
 import HealthStateSection from './HealthStateSection';
+import { ScomQuery } from 'types';
+
+// Query types edited by the alerts and health tabs.
+const alertQueryTypes: Array<ScomQuery['type']> = ['alerts', 'alertAggregation', 'alertAnnotations'];
+const healthQueryTypes: Array<ScomQuery['type']> = ['state', 'healthExplorer', 'stateHistory', 'availability', 'maintenance'];
 
 // onRunQuery calls 'query' in the backend.
 // datasource calls 'CallResource' in the backend.
@@ -19,12 +24,12 @@ export function QueryEditor() {
   }, {
     label: 'Alerts',
     icon: 'bell' as IconName,
-    active: query.type === 'alerts',
+    active: alertQueryTypes.includes(query.type),
     element: <AlertsSection />
   }, {
     label: 'Health',
     icon:'heart' as IconName,
-    active: query.type === 'state',
+    active: healthQueryTypes.includes(query.type),
     element: <HealthStateSection />
   }])
 
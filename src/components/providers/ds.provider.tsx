@@ -1,13 +1,14 @@
 import { ScomDataSource } from "datasource";
 import React, { createContext, useContext } from "react";
-import { AlertQuery, MonitoringClass, MonitoringGroup, MonitoringObject, PerformanceCounter, PerformanceQuery, ScomQuery, StateQuery } from "types";
+import { AlertAggregationQuery, AlertAnnotationQuery, AlertQuery, HealthQuery, MonitoringClass, MonitoringGroup, MonitoringObject, PerformanceCounter, PerformanceOptions, PerformanceQuery, ScomQuery } from "types";
 
 interface DsContextProps {
     query: ScomQuery
-    getAlerts: (criteria: string) => Promise<void>
-    getState(classes: MonitoringClass[], instances: MonitoringObject[]): Promise<void>
-    getStateByGroup(groups: MonitoringGroup, classes: MonitoringClass[]): Promise<void>
-    getPerformance: (counters: PerformanceCounter[], classes: MonitoringClass[], instances?: MonitoringObject[], groups?: MonitoringGroup[]) => Promise<void>;
+    /** Whether the features needing the server extension are available. */
+    serverExtension: boolean
+    getAlerts: (alertQuery: AlertQuery | AlertAggregationQuery | AlertAnnotationQuery) => Promise<void>
+    getHealth(healthQuery: HealthQuery): Promise<void>
+    getPerformance: (counters: PerformanceCounter[], classes: MonitoringClass[], instances?: MonitoringObject[], groups?: MonitoringGroup[], options?: PerformanceOptions) => Promise<void>;
     getClasses: (criteria: string) => Promise<MonitoringClass[]>;
     getMonitoringObjects: (criteria: string) => Promise<MonitoringObject[]>;
     getMonitoringObjectsByGroup: (groupClassName: string) => Promise<MonitoringObject[]>;
@@ -63,9 +64,10 @@ export const DsProvider = ({ children, datasource, query, onChange, onRunQuery }
             const counters = await datasource.getResource<PerformanceCounter[]>('getCounters', { entityIds });
             return counters;
         },
-        getPerformance: async (counters: PerformanceCounter[], classes: MonitoringClass[], instances?: MonitoringObject[], groups?: MonitoringGroup[]) => {
+        getPerformance: async (counters: PerformanceCounter[], classes: MonitoringClass[], instances?: MonitoringObject[], groups?: MonitoringGroup[], options?: PerformanceOptions) => {
             const performanceQuery: PerformanceQuery = {
                 ...query,
+                ...options,
                 type: 'performance',
                 groups,
                 classes,
@@ -77,42 +79,19 @@ export const DsProvider = ({ children, datasource, query, onChange, onRunQuery }
 
             onRunQuery();
         },
-        getAlerts: async (criteria: string) => {
-            const alertQuery: AlertQuery = {
-                ...query,
-                type: 'alerts',
-                criteria
-            }
-
-            onChange(alertQuery);
-            onRunQuery();
-        },
-        getState: async (classes: MonitoringClass[], instances: MonitoringObject[]) => {
-            const stateQuery: StateQuery = {
-                ...query,
-                type: 'state',
-                classes,
-                groups: undefined,
-                instances
-            }
-            onChange(stateQuery);
+        getAlerts: async (alertQuery: AlertQuery | AlertAggregationQuery | AlertAnnotationQuery) => {
+            onChange({ ...query, ...alertQuery });
             onRunQuery();
         },
-        getStateByGroup: async (group: MonitoringGroup, classes: MonitoringClass[]) => {
-            const stateQuery: StateQuery = {
-                ...query,
-                type: 'state',
-                groups: [group],
-                classes,
-                instances: undefined
-            }
-            onChange(stateQuery)
+        getHealth: async (healthQuery: HealthQuery) => {
+            onChange({ ...query, ...healthQuery });
             onRunQuery();
         },
         getClassesForObject: async (id: string) => {
             const classes = await datasource.getResource<MonitoringClass[]>('getClassesForObject', { objectId: id });
             return classes;
         },
+        serverExtension: datasource.instanceSettings.jsonData.serverExtension ?? false,
         query
     }
 
@@ -1,10 +1,20 @@
 import { CoreApp, DataSourceInstanceSettings } from '@grafana/data';
 import { DataSourceWithBackend } from '@grafana/runtime';
-import { AlertQuery, ScomDataSourceOptions, ScomQuery } from './types';
+import {
+  AlertAction,
+  AlertActionRequest,
+  AlertQuery,
+  MaintenanceAction,
+  MaintenanceActionRequest,
+  ScomDataSourceOptions,
+  ScomQuery,
+} from './types';
 
 export class ScomDataSource extends DataSourceWithBackend<ScomQuery, ScomDataSourceOptions> {
   constructor(instanceSettings: DataSourceInstanceSettings<ScomDataSourceOptions>) {
     super(instanceSettings);
+    // Annotation queries are regular queries returning the alertAnnotations frame.
+    this.annotations = {};
   }
 
   getDefaultQuery(app: CoreApp): Partial<AlertQuery> {
@@ -14,6 +24,22 @@ export class ScomDataSource extends DataSourceWithBackend<ScomQuery, ScomDataSou
     }
   }
 
+  /**
+   * Applies an alert action in SCOM. The Grafana user performing it is logged by the backend.
+   * Needs the server extension, see the README.
+   */
+  updateAlerts(action: AlertAction, request: AlertActionRequest) {
+    return this.postResource(action, request);
+  }
+
+  /**
+   * Starts, updates or stops maintenance mode in SCOM. The Grafana user is recorded in the comment.
+   * Needs the server extension, see the README.
+   */
+  updateMaintenance(action: MaintenanceAction, request: MaintenanceActionRequest) {
+    return this.postResource(action, request);
+  }
+
   // // * Returns options for variable use. Without this it gives error?
   // async metricFindQuery(query: ScomQuery, options?: any) {
   //   // const values = [{ text: 'Option 1' }, { text: 'Option 2' }];
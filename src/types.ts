@@ -2,29 +2,190 @@ import { DataSourceJsonData } from '@grafana/data';
 import { DataQuery } from '@grafana/schema';
 
 export interface ScomQuery extends DataQuery {
-  type: 'state' | 'alerts' | 'performance'
+  type: 'state' | 'alerts' | 'alertAggregation' | 'alertAnnotations' | 'maintenance' | 'healthExplorer' | 'stateHistory' | 'availability' | 'performance'
 }
 
-export interface StateQuery extends ScomQuery {
-  type: 'state';
+/**
+ * Objects a query applies to: the instances of the classes, or the members of the groups,
+ * optionally limited to the classes.
+ */
+export interface ObjectSelection {
   classes?: MonitoringClass[];
   groups?: MonitoringGroup[];
   instances?: MonitoringObject[];
 }
 
+export interface StateQuery extends ScomQuery, ObjectSelection {
+  type: 'state';
+}
+
+/**
+ * Returns the monitor rollup tree of the selected objects as a parent/child table,
+ * followed by nodes and edges frames for the node graph panel.
+ */
+export interface HealthExplorerQuery extends ScomQuery, ObjectSelection {
+  type: 'healthExplorer';
+}
+
+/**
+ * Returns the health state transitions over the time range, a frame per object or per monitor,
+ * for the state timeline panel.
+ */
+export interface StateHistoryQuery extends ScomQuery, ObjectSelection {
+  type: 'stateHistory';
+  level?: 'object' | 'monitor';
+}
+
+/**
+ * Returns the percentage of time every object, and every selected group, spent in each health state.
+ */
+export interface AvailabilityQuery extends ScomQuery, ObjectSelection {
+  type: 'availability';
+  /** Leaves maintenance windows out of the availability instead of counting them as downtime. */
+  excludeMaintenance?: boolean;
+}
+
+/**
+ * Returns the maintenance mode windows of the selected objects, groups or classes as annotation regions.
+ */
+export interface MaintenanceQuery extends ScomQuery, ObjectSelection {
+  type: 'maintenance';
+}
+
+/**
+ * Queries of the health tab, which all select objects by class, group and instance.
+ */
+export type HealthQuery = StateQuery | HealthExplorerQuery | StateHistoryQuery | AvailabilityQuery | MaintenanceQuery;
+
 export interface AlertQuery extends ScomQuery {
   type: 'alerts';
   criteria?: string;
+  timeFilter?: 'raised' | 'modified';
+  /** SCOM alert properties to return, e.g. owner, ticketid, resolutionstate, customfield1. */
+  columns?: string[];
+  /** Structured criteria, used when no raw criteria is given. */
+  filter?: AlertFilter;
+}
+
+/**
+ * Counts the alerts matched by the alert criteria per distinct value of the groupBy properties.
+ */
+export interface AlertAggregationQuery extends Omit<AlertQuery, 'type'> {
+  type: 'alertAggregation';
+  groupBy: AlertGroupBy[];
+}
+
+export type AlertGroupBy = 'severity' | 'resolutionstate' | 'object' | 'class' | 'name';
+
+/**
+ * Returns the alerts matched by the alert criteria as annotations spanning from time raised to time resolved.
+ * Without a time filter, alerts active at any point of the time range are returned.
+ */
+export interface AlertAnnotationQuery extends Omit<AlertQuery, 'type' | 'columns'> {
+  type: 'alertAnnotations';
+}
+
+/**
+ * Body posted to the alert action resources: closeAlerts, setAlertResolutionState, setAlertOwner and setAlertTicketId.
+ * Only editors and admins may post them.
+ */
+export interface AlertActionRequest {
+  alertIds: string[];
+  resolutionState?: number;
+  owner?: string;
+  ticketId?: string;
+  comment?: string;
+}
+
+export type AlertAction = 'closeAlerts' | 'setAlertResolutionState' | 'setAlertOwner' | 'setAlertTicketId';
+
+/**
+ * Body posted to the maintenance resources: startMaintenance, updateMaintenance and stopMaintenance.
+ * Duration and reason are required to start or update maintenance mode. Only editors and admins may post them.
+ */
+export interface MaintenanceActionRequest {
+  objectIds: string[];
+  durationMinutes?: number;
+  reason?: MaintenanceReason;
+  comment?: string;
+  /** Includes contained objects, such as group members. Defaults to true. */
+  recursive?: boolean;
+}
+
+export type MaintenanceAction = 'startMaintenance' | 'updateMaintenance' | 'stopMaintenance';
+
+export type MaintenanceReason =
+  | 'PlannedOther'
+  | 'UnplannedOther'
+  | 'PlannedHardwareMaintenance'
+  | 'UnplannedHardwareMaintenance'
+  | 'PlannedHardwareInstallation'
+  | 'UnplannedHardwareInstallation'
+  | 'PlannedOperatingSystemReconfiguration'
+  | 'UnplannedOperatingSystemReconfiguration'
+  | 'PlannedApplicationMaintenance'
+  | 'UnplannedApplicationMaintenance'
+  | 'ApplicationInstallation'
+  | 'ApplicationUnresponsive'
+  | 'ApplicationUnstable'
+  | 'SecurityIssue'
+  | 'LossOfNetworkConnectivity';
+
+export interface AlertFilter {
+  severities?: string[];
+  priorities?: string[];
+  resolutionStates?: number[];
+  objectIds?: string[];
+  classIds?: string[];
+  nameContains?: string;
+  owner?: string;
+  maxAgeMinutes?: number;
 }
 
-export interface PerformanceQuery extends ScomQuery {
+export interface PerformanceQuery extends ScomQuery, ObjectSelection {
   type: 'performance';
-  classes?: MonitoringClass[];
   counters?: PerformanceCounter[];
-  groups?: MonitoringGroup[];
-  instances?: MonitoringObject[];
+  aggregation?: Aggregation;
+  format?: PerformanceFormat;
+  mode?: 'series' | 'summary';
+  ranking?: PerformanceRanking;
+  rollup?: PerformanceRollup;
 }
 
+/**
+ * Query options set next to the object and counter selection.
+ */
+export type PerformanceOptions = Pick<PerformanceQuery, 'aggregation' | 'format' | 'mode' | 'ranking' | 'rollup'>;
+
+/**
+ * Aggregates the members of every selected group into a single series per counter.
+ */
+export interface PerformanceRollup {
+  aggregation: 'avg' | 'sum' | 'min' | 'max' | 'percentile';
+  percentile?: number;
+}
+
+/**
+ * Keeps only the top or bottom `limit` series ranked by a statistic over the time range.
+ */
+export interface PerformanceRanking {
+  order: 'top' | 'bottom';
+  limit: number;
+  by: 'avg' | 'max' | 'last';
+}
+
+/**
+ * table: a frame per series with object and counter columns (default).
+ * timeseries: a labelled frame per series.
+ * wide: a single frame with a labelled field per series.
+ */
+export type PerformanceFormat = 'table' | 'timeseries' | 'wide';
+
+/**
+ * Aggregation used when resampling performance series to the panel interval.
+ */
+export type Aggregation = 'none' | 'avg' | 'min' | 'max' | 'last' | 'sum' | 'count';
+
 export const DEFAULT_QUERY: Partial<AlertQuery> = {
   type: 'alerts',
   criteria: 'Severity = 2 AND ResolutionState = 0'
@@ -38,8 +199,19 @@ export interface ScomDataSourceOptions extends DataSourceJsonData {
   userName?: string;
   password?: string;
   isSkipTlsVerifyCheck?: boolean;
+  authMode?: AuthMode;
+  sessionTimeout?: number;
+  maxConcurrency?: number;
+  /** Enables the features that need endpoints outside the SCOM REST API, see the README. */
+  serverExtension?: boolean;
 }
 
+/**
+ * basic: user name and password are posted to the SCOM authenticate endpoint.
+ * windows: NTLM/Negotiate handshake against a SCOM web console using Windows authentication.
+ */
+export type AuthMode = 'basic' | 'windows';
+
 /**
  * Value that is used in the backend, but never sent over HTTP to the frontend
  */